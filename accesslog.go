@@ -0,0 +1,267 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Access log formats.
+const (
+	// LogJSON is one JSON object per line with the mapped request, status, bytes, latency and remote address.
+	LogJSON = "json"
+	// LogCommon is the Common Log Format.
+	LogCommon = "common"
+	// LogCombined is the Combined Log Format (Common Log Format with referer and user agent).
+	LogCombined = "combined"
+)
+
+var errLogFormat = errors.New("unknown access log format")
+
+const clfTime = "02/Jan/2006:15:04:05 -0700"
+
+// AccessLogHandler returns a handler which serves requests with next and writes one line per exchange
+// to w in the given format using DefaultMapper.
+func AccessLogHandler(next http.Handler, w io.Writer, format string, opts ...Option) (http.Handler, error) {
+	return DefaultMapper.AccessLogHandler(next, w, format, opts...)
+}
+
+// AccessLogHandler returns a handler which serves requests with next and writes one line per exchange
+// to w in the given format (LogJSON, LogCommon or LogCombined). Lines are written by a single Write call.
+//
+// In LogJSON format the request is mapped with the given options before calling next,
+// so a mapped body remains readable by next. Mapping errors are logged in the errors field.
+// An echo handler of the same Mapper created with the same option slice (like passing opts... to both)
+// reuses this mapping instead of mapping the request again.
+// In LogCommon and LogCombined format the RedactFunc options apply to the request URI, referer,
+// user agent and Basic authentication user.
+func (m *Mapper) AccessLogHandler(next http.Handler, w io.Writer, format string, opts ...Option) (http.Handler, error) {
+	switch format {
+	case LogJSON, LogCommon, LogCombined:
+	default:
+		return nil, errLogFormat
+	}
+
+	return &accessLog{mapper: m, next: next, out: w, format: format, opts: opts}, nil
+}
+
+type accessLog struct {
+	mapper *Mapper
+	next   http.Handler
+	out    io.Writer
+	format string
+	opts   []Option
+	mu     sync.Mutex
+}
+
+func (l *accessLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	var (
+		dict Dict
+		err  error
+	)
+
+	if l.format == LogJSON {
+		dict, err = l.mapper.MapRequestWithOptions(r, l.opts...)
+		r = shareMapping(r, l.mapper, l.opts, dict, err)
+	}
+
+	rec := &responseRecorder{ResponseWriter: w}
+
+	l.next.ServeHTTP(rec.writer(), r)
+
+	var line []byte
+
+	if l.format == LogJSON {
+		line = jsonLine(r, rec, start, dict, err)
+	} else {
+		line = clfLine(r, rec, start, l.format == LogCombined, l.mapper.newOptions(l.opts))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, _ = l.out.Write(line)
+}
+
+func jsonLine(r *http.Request, rec *responseRecorder, start time.Time, dict Dict, err error) []byte {
+	entry := Dict{
+		"time":    start.UTC().Format(time.RFC3339Nano),
+		"status":  rec.statusCode(),
+		"bytes":   rec.size,
+		"latency": time.Since(start).Seconds(),
+	}
+
+	if len(r.RemoteAddr) != 0 {
+		entry["remote"] = r.RemoteAddr
+	}
+
+	if dict != nil {
+		entry["request"] = dict
+	}
+
+	if err != nil {
+		entry["errors"] = errorDicts(err)
+	}
+
+	if rec.hijacked {
+		entry["hijacked"] = true
+	}
+
+	data, jerr := json.Marshal(entry)
+	if jerr != nil {
+		data, _ = json.Marshal(Dict{"time": entry["time"], "errors": []Dict{{"message": jerr.Error()}}})
+	}
+
+	return append(data, '\n')
+}
+
+// clfLine creates Common or Combined Log Format line.
+// The request URI, referer, user agent and user are redacted by the options, like the mapped request.
+func clfLine(r *http.Request, rec *responseRecorder, start time.Time, combined bool, o *options) []byte {
+	var buff bytes.Buffer
+
+	host := r.RemoteAddr
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	uri, referer, agent, user := clfRequest(r, o)
+
+	size := "-"
+	if rec.size != 0 {
+		size = strconv.FormatInt(rec.size, 10)
+	}
+
+	buff.WriteString(clfField(host))
+	buff.WriteString(" - ")
+	buff.WriteString(clfField(user))
+	buff.WriteString(" [" + start.Format(clfTime) + "] ")
+	buff.WriteString(strconv.Quote(r.Method + " " + uri + " " + r.Proto))
+	buff.WriteString(" " + strconv.Itoa(rec.statusCode()) + " " + size)
+
+	if combined {
+		buff.WriteString(" " + clfQuote(referer) + " " + clfQuote(agent))
+	}
+
+	buff.WriteByte('\n')
+
+	return buff.Bytes()
+}
+
+// clfRequest returns the logged request attributes in the same Dict locations as the mapped request,
+// so RedactFunc options (query, header and authorization rules) apply to them.
+// The referer is redacted by the query rules too.
+func clfRequest(r *http.Request, o *options) (uri, referer, agent, user string) {
+	out := Dict{
+		"version":    r.Proto,
+		"method":     r.Method,
+		"path":       r.URL.Path,
+		"headers":    Dict{"Referer": []string{r.Referer()}, "User-Agent": []string{r.UserAgent()}},
+		"connection": Dict{"request_uri": r.RequestURI},
+	}
+
+	scheme := strings.SplitN(r.Header.Get("Authorization"), " ", 2)[0]
+
+	if name, _, ok := r.BasicAuth(); ok {
+		out["authorization"] = Dict{scheme: Dict{"username": name}}
+	}
+
+	o.redactAll(out)
+
+	uri = clfValue(out, "connection", "request_uri")
+	agent = clfValue(out, "headers", "User-Agent")
+
+	if user = clfValue(out, "authorization", scheme, "username"); len(user) == 0 {
+		user = clfValue(out, "authorization", scheme)
+	}
+
+	ref := Dict{"connection": Dict{"request_uri": clfValue(out, "headers", "Referer")}}
+
+	o.redactAll(ref)
+
+	referer = clfValue(ref, "connection", "request_uri")
+
+	return uri, referer, agent, user
+}
+
+// clfValue returns the string (or first string array element) at the given keys of a redacted Dict.
+func clfValue(d Dict, keys ...string) string {
+	var v interface{} = d
+
+	for _, key := range keys {
+		dict, ok := v.(Dict)
+		if !ok {
+			return ""
+		}
+
+		v = dict[key]
+	}
+
+	switch val := v.(type) {
+	case string:
+		return val
+	case []string:
+		if len(val) != 0 {
+			return val[0]
+		}
+	case []interface{}:
+		if len(val) != 0 {
+			return fmt.Sprint(val[0])
+		}
+	}
+
+	return ""
+}
+
+func clfQuote(value string) string {
+	if len(value) == 0 {
+		return `"-"`
+	}
+
+	return strconv.Quote(value)
+}
+
+// clfField returns value or "-" if empty, quoted if it contains space or characters to escape.
+func clfField(value string) string {
+	if len(value) == 0 {
+		return "-"
+	}
+
+	if q := strconv.Quote(value); q[1:len(q)-1] != value || strings.ContainsRune(value, ' ') {
+		return q
+	}
+
+	return value
+}
@@ -0,0 +1,267 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/szkiba/yare"
+)
+
+func created(t *testing.T) http.Handler {
+	t.Helper()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(body)
+	})
+}
+
+func TestAccessLogHandlerJSON(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+
+	h, err := yare.NewMapper().AccessLogHandler(created(t), &out, yare.LogJSON, yare.WithBody(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "http://localhost/path?foo=bar", strings.NewReader("hello"))
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated || rec.Body.String() != "hello" {
+		t.Errorf("response = %d %s, want %d hello", rec.Code, rec.Body.String(), http.StatusCreated)
+	}
+
+	if !strings.HasSuffix(out.String(), "}\n") || strings.Count(out.String(), "\n") != 1 {
+		t.Fatalf("access log = %q, want one JSON line", out.String())
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got["status"] != float64(http.StatusCreated) || got["bytes"] != float64(5) || got["remote"] != "192.0.2.1:1234" {
+		t.Errorf("status, bytes, remote = %v, %v, %v, want 201, 5, 192.0.2.1:1234",
+			got["status"], got["bytes"], got["remote"])
+	}
+
+	if _, ok := got["latency"].(float64); !ok {
+		t.Errorf("latency = %v, want number", got["latency"])
+	}
+
+	if _, ok := got["time"].(string); !ok {
+		t.Errorf("time = %v, want string", got["time"])
+	}
+
+	request, _ := got["request"].(map[string]interface{})
+
+	want := map[string]interface{}{"method": "POST", "path": "/path", "query": map[string]interface{}{"foo": "bar"}}
+	for key, value := range want {
+		if !reflect.DeepEqual(request[key], value) {
+			t.Errorf("request[%s] = %v, want %v", key, request[key], value)
+		}
+	}
+}
+
+func TestAccessLogHandlerSharedMapping(t *testing.T) {
+	t.Parallel()
+
+	var calls int32
+
+	m := yare.NewMapper()
+	_ = m.RegisterContentType("application/x-count", func(in []byte) (yare.Dict, error) {
+		atomic.AddInt32(&calls, 1)
+
+		return yare.Dict{"data": string(in)}, nil
+	})
+
+	opts := []yare.Option{yare.WithBody(true)}
+
+	var out bytes.Buffer
+
+	h, err := m.AccessLogHandler(m.EchoHandlerWithOptions(opts...), &out, yare.LogJSON, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "http://localhost/", strings.NewReader("hello"))
+	req.Header.Set("Content-Type", "application/x-count")
+
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(rec, req)
+
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("parser calls = %d, want 1", n)
+	}
+
+	echoed, _ := yare.ParseJSON(rec.Body.Bytes())
+
+	var logged map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &logged); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{"data": "hello"}
+	if body := logged["request"].(map[string]interface{})["body"]; !reflect.DeepEqual(body, want) {
+		t.Errorf("logged body = %v, want %v", body, want)
+	}
+
+	if !reflect.DeepEqual(echoed["body"], want) {
+		t.Errorf("echoed body = %v, want %v", echoed["body"], want)
+	}
+}
+
+func TestAccessLogHandlerText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: yare.LogCommon,
+			want: `^192\.0\.2\.1 - foo \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] ` +
+				`"POST /path\?foo=bar HTTP/1\.1" 201 5\n$`,
+		},
+		{
+			format: yare.LogCombined,
+			want: `^192\.0\.2\.1 - foo \[[^]]+\] "POST /path\?foo=bar HTTP/1\.1" 201 5 ` +
+				`"http://example\.com/" "test \\"agent\\""\n$`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.format, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+
+			h, err := yare.AccessLogHandler(created(t), &out, tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/path?foo=bar", strings.NewReader("hello"))
+			req.SetBasicAuth("foo", "secret")
+			req.Header.Set("Referer", "http://example.com/")
+			req.Header.Set("User-Agent", `test "agent"`)
+
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			if !regexp.MustCompile(tt.want).MatchString(out.String()) {
+				t.Errorf("access log = %q, want match %s", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestAccessLogHandlerTextRedact(t *testing.T) {
+	t.Parallel()
+
+	redactor := yare.DefaultRedactor()
+	if err := redactor.AddRule("$.authorization.Basic.username"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: yare.LogCommon,
+			want: `^192\.0\.2\.1 - \[REDACTED\] \[[^]]+\] ` +
+				`"GET /p\?api_key=%5BREDACTED%5D&token=%5BREDACTED%5D&q=1 HTTP/1\.1" 201 2\n$`,
+		},
+		{
+			format: yare.LogCombined,
+			want: `^192\.0\.2\.1 - \[REDACTED\] \[[^]]+\] ` +
+				`"GET /p\?api_key=%5BREDACTED%5D&token=%5BREDACTED%5D&q=1 HTTP/1\.1" 201 2 ` +
+				`"http://example\.com/\?secret=%5BREDACTED%5D" "test"\n$`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.format, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+
+			h, err := yare.AccessLogHandler(created(t), &out, tt.format, yare.WithRedactor(redactor))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/p?api_key=topsecret&token=abc&q=1", strings.NewReader("ok"))
+			req.SetBasicAuth("foo", "secret")
+			req.Header.Set("Referer", "http://example.com/?secret=xyz")
+			req.Header.Set("User-Agent", "test")
+
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			if !regexp.MustCompile(tt.want).MatchString(out.String()) {
+				t.Errorf("access log = %q, want match %s", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestAccessLogHandlerEmpty(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+
+	h, _ := yare.AccessLogHandler(http.NotFoundHandler(), &out, yare.LogCommon)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = ""
+
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	want := `"GET / HTTP/1.1" 404 19`
+	if !strings.Contains(out.String(), want) || !strings.HasPrefix(out.String(), "- - - [") {
+		t.Errorf("access log = %q, want %s", out.String(), want)
+	}
+
+	if _, err := yare.AccessLogHandler(http.NotFoundHandler(), &out, "apache"); err == nil {
+		t.Error("AccessLogHandler() error = nil, want error")
+	}
+}
@@ -0,0 +1,373 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// Binary serialization formats share a JSON friendly representation of values
+// which have no JSON counterpart (similar to MongoDB Extended JSON):
+//
+//   byte string             {"$binary": "<base64>"}
+//   MessagePack extension   {"$ext": <type>, "$binary": "<base64>"}
+//   CBOR tag                {"$tag": <number>, "$value": <content>}
+//   timestamp               "<RFC 3339 string in UTC>"
+//   big integer             <number>
+//
+// BSON specific types are mapped to $oid, $binary/$subtype, $regex/$options, $timestamp,
+// $numberDecimal, $code/$scope, $symbol, $dbPointer, $minKey and $maxKey objects.
+
+const msgpackTimestampExt = -1
+
+var (
+	errTrailingBytes = errors.New("trailing bytes after top level value")
+	errLengthHeader  = errors.New("length header exceeds remaining input")
+)
+
+// ParseMsgPack is a ParserFunc for parsing MessagePack to Dict.
+//
+// Can use as RegisterContentType parser argument.
+func ParseMsgPack(in []byte) (Dict, error) {
+	r := bytes.NewReader(in)
+	d := msgpack.NewDecoder(r)
+
+	v, err := decodeMsgPack(d, r)
+	if err != nil {
+		return nil, wrapError(fmt.Errorf("msgpack: %w", err))
+	}
+
+	if r.Len() != 0 {
+		return nil, wrapError(fmt.Errorf("msgpack: %w", errTrailingBytes))
+	}
+
+	return topLevelDict("msgpack", v)
+}
+
+// checkMsgPackLen rejects length headers announcing more than the remaining input could hold
+// (at least size bytes per item), before anything is allocated for them.
+func checkMsgPackLen(r *bytes.Reader, n, size int) error {
+	if n > r.Len()/size {
+		return fmt.Errorf("%w: %d", errLengthHeader, n)
+	}
+
+	return nil
+}
+
+func decodeMsgPack(d *msgpack.Decoder, r *bytes.Reader) (interface{}, error) {
+	c, err := d.PeekCode()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case msgpcode.IsFixedMap(c) || c == msgpcode.Map16 || c == msgpcode.Map32:
+		return decodeMsgPackMap(d, r)
+	case msgpcode.IsFixedArray(c) || c == msgpcode.Array16 || c == msgpcode.Array32:
+		return decodeMsgPackArray(d, r)
+	case msgpcode.IsExt(c):
+		return decodeMsgPackExt(d, r)
+	case msgpcode.IsBin(c):
+		data, err := d.DecodeBytes()
+		if err != nil {
+			return nil, err
+		}
+
+		return binaryValue(data), nil
+	default:
+		return d.DecodeInterface()
+	}
+}
+
+func decodeMsgPackMap(d *msgpack.Decoder, r *bytes.Reader) (interface{}, error) {
+	n, err := d.DecodeMapLen()
+	if err != nil || n < 0 {
+		return nil, err
+	}
+
+	// each entry has at least one byte key and one byte value
+	if err := checkMsgPackLen(r, n, 2); err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]interface{}, n)
+
+	for i := 0; i < n; i++ {
+		key, err := d.DecodeInterface()
+		if err != nil {
+			return nil, err
+		}
+
+		val, err := decodeMsgPack(d, r)
+		if err != nil {
+			return nil, err
+		}
+
+		out[mapKey(key)] = val
+	}
+
+	return out, nil
+}
+
+func decodeMsgPackArray(d *msgpack.Decoder, r *bytes.Reader) (interface{}, error) {
+	n, err := d.DecodeArrayLen()
+	if err != nil || n < 0 {
+		return nil, err
+	}
+
+	if err := checkMsgPackLen(r, n, 1); err != nil {
+		return nil, err
+	}
+
+	out := make([]interface{}, n)
+
+	for i := range out {
+		if out[i], err = decodeMsgPack(d, r); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+func decodeMsgPackExt(d *msgpack.Decoder, r *bytes.Reader) (interface{}, error) {
+	id, n, err := d.DecodeExtHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkMsgPackLen(r, n, 1); err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, n)
+	if err := d.ReadFull(data); err != nil {
+		return nil, err
+	}
+
+	if id == msgpackTimestampExt {
+		if t, ok := msgpackTimestamp(data); ok {
+			return timeValue(t), nil
+		}
+	}
+
+	return map[string]interface{}{"$ext": id, "$binary": base64.StdEncoding.EncodeToString(data)}, nil
+}
+
+func msgpackTimestamp(data []byte) (time.Time, bool) {
+	const (
+		nsecShift = 34
+		secMask   = 1<<nsecShift - 1
+	)
+
+	switch len(data) {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(data)), 0), true
+	case 8:
+		v := binary.BigEndian.Uint64(data)
+
+		return time.Unix(int64(v&secMask), int64(v>>nsecShift)), true
+	case 12:
+		return time.Unix(int64(binary.BigEndian.Uint64(data[4:])), int64(binary.BigEndian.Uint32(data[:4]))), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// ParseCBOR is a ParserFunc for parsing CBOR to Dict.
+//
+// Can use as RegisterContentType parser argument.
+func ParseCBOR(in []byte) (Dict, error) {
+	var v interface{}
+
+	if err := cbor.Unmarshal(in, &v); err != nil {
+		return nil, wrapError(err)
+	}
+
+	return topLevelDict("cbor", normalizeCBOR(v))
+}
+
+func normalizeCBOR(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(t))
+
+		for k, e := range t {
+			out[mapKey(k)] = normalizeCBOR(e)
+		}
+
+		return out
+	case []interface{}:
+		for i, e := range t {
+			t[i] = normalizeCBOR(e)
+		}
+
+		return t
+	case []byte:
+		return binaryValue(t)
+	case time.Time:
+		return timeValue(t)
+	case big.Int:
+		return json.Number(t.String())
+	case *big.Int:
+		return json.Number(t.String())
+	case cbor.Tag:
+		return map[string]interface{}{"$tag": t.Number, "$value": normalizeCBOR(t.Content)}
+	default:
+		return v
+	}
+}
+
+// ParseBSON is a ParserFunc for parsing BSON document to Dict.
+//
+// Can use as RegisterContentType parser argument.
+func ParseBSON(in []byte) (Dict, error) {
+	doc := bson.Raw(in)
+
+	if err := doc.Validate(); err != nil {
+		return nil, wrapError(fmt.Errorf("bson: %w", err))
+	}
+
+	return Dict(bsonDocument(doc)), nil
+}
+
+func bsonDocument(doc bson.Raw) map[string]interface{} {
+	// must be ok because document is already validated
+	elems, _ := doc.Elements()
+	out := make(map[string]interface{}, len(elems))
+
+	for _, e := range elems {
+		out[e.Key()] = bsonValue(e.Value())
+	}
+
+	return out
+}
+
+func bsonArray(doc bson.Raw) []interface{} {
+	// must be ok because document is already validated
+	values, _ := doc.Values()
+	out := make([]interface{}, len(values))
+
+	for i, v := range values {
+		out[i] = bsonValue(v)
+	}
+
+	return out
+}
+
+func bsonValue(v bson.RawValue) interface{} {
+	switch v.Type {
+	case bsontype.Double:
+		return v.Double()
+	case bsontype.String:
+		return v.StringValue()
+	case bsontype.EmbeddedDocument:
+		return bsonDocument(v.Document())
+	case bsontype.Array:
+		return bsonArray(v.Array())
+	case bsontype.Binary:
+		subtype, data := v.Binary()
+
+		return map[string]interface{}{"$binary": base64.StdEncoding.EncodeToString(data), "$subtype": subtype}
+	case bsontype.ObjectID:
+		return map[string]interface{}{"$oid": v.ObjectID().Hex()}
+	case bsontype.Boolean:
+		return v.Boolean()
+	case bsontype.DateTime:
+		return timeValue(v.Time())
+	case bsontype.Regex:
+		pattern, options := v.Regex()
+
+		return map[string]interface{}{"$regex": pattern, "$options": options}
+	case bsontype.DBPointer:
+		ns, oid := v.DBPointer()
+
+		return map[string]interface{}{"$dbPointer": map[string]interface{}{"$ref": ns, "$id": map[string]interface{}{"$oid": oid.Hex()}}}
+	case bsontype.JavaScript:
+		return map[string]interface{}{"$code": v.JavaScript()}
+	case bsontype.Symbol:
+		return map[string]interface{}{"$symbol": v.Symbol()}
+	case bsontype.CodeWithScope:
+		code, scope := v.CodeWithScope()
+
+		return map[string]interface{}{"$code": code, "$scope": bsonDocument(scope)}
+	case bsontype.Int32:
+		return v.Int32()
+	case bsontype.Timestamp:
+		t, i := v.Timestamp()
+
+		return map[string]interface{}{"$timestamp": map[string]interface{}{"t": t, "i": i}}
+	case bsontype.Int64:
+		return v.Int64()
+	case bsontype.Decimal128:
+		return map[string]interface{}{"$numberDecimal": v.Decimal128().String()}
+	case bsontype.MinKey:
+		return map[string]interface{}{"$minKey": 1}
+	case bsontype.MaxKey:
+		return map[string]interface{}{"$maxKey": 1}
+	default: // Null and Undefined
+		return nil
+	}
+}
+
+func binaryValue(data []byte) interface{} {
+	return map[string]interface{}{"$binary": base64.StdEncoding.EncodeToString(data)}
+}
+
+func timeValue(t time.Time) interface{} {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func mapKey(key interface{}) string {
+	switch k := key.(type) {
+	case string:
+		return k
+	case []byte:
+		return string(k)
+	default:
+		return fmt.Sprint(k)
+	}
+}
+
+func topLevelDict(format string, v interface{}) (Dict, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, wrapError(fmt.Errorf("%s: %w, got %s", format, errNotMapping, reflect.TypeOf(v)))
+	}
+
+	return Dict(m), nil
+}
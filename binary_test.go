@@ -0,0 +1,319 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/szkiba/yare"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var testTime = time.Date(2021, 2, 6, 12, 30, 0, 500, time.UTC)
+
+func mustMarshal(t *testing.T, marshal func(interface{}) ([]byte, error), v interface{}) string {
+	t.Helper()
+
+	data, err := marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+func TestParseMsgPack(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		in string
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		want    yare.Dict
+		wantErr bool
+	}{
+		{
+			name: "normal",
+			args: args{in: mustMarshal(t, msgpack.Marshal, map[string]interface{}{
+				"foo":    "bar",
+				"list":   []interface{}{1, true, nil},
+				"bytes":  []byte("Hi"),
+				"time":   testTime,
+				"nested": map[int]string{1: "one"},
+			})},
+			want: yare.Dict{
+				"foo":    "bar",
+				"list":   []interface{}{int8(1), true, nil},
+				"bytes":  map[string]interface{}{"$binary": "SGk="},
+				"time":   "2021-02-06T12:30:00.0000005Z",
+				"nested": map[string]interface{}{"1": "one"},
+			},
+		},
+		{
+			name: "extension",
+			args: args{in: "\x81\xa3ext\xd4\x05\x01"},
+			want: yare.Dict{"ext": map[string]interface{}{"$ext": int8(5), "$binary": "AQ=="}},
+		},
+		{
+			name:    "array",
+			args:    args{in: mustMarshal(t, msgpack.Marshal, []int{1, 2})},
+			wantErr: true,
+		},
+		{
+			name:    "trailing",
+			args:    args{in: mustMarshal(t, msgpack.Marshal, map[string]int{"foo": 1}) + "\x01"},
+			wantErr: true,
+		},
+		{
+			name:    "truncated",
+			args:    args{in: "\x81\xa3foo"},
+			wantErr: true,
+		},
+		{
+			name:    "array32 length header",
+			args:    args{in: "\xdd\x7f\xff\xff\xff"},
+			wantErr: true,
+		},
+		{
+			name:    "map32 length header",
+			args:    args{in: "\xdf\x7f\xff\xff\xff"},
+			wantErr: true,
+		},
+		{
+			name:    "ext32 length header",
+			args:    args{in: "\x81\xa1a\xc9\x7f\xff\xff\xff\x05"},
+			wantErr: true,
+		},
+		{
+			name:    "nested array32 length header",
+			args:    args{in: "\x81\xa1a\xdd\xff\xff\xff\xff"},
+			wantErr: true,
+		},
+		{
+			name:    "str32 length header",
+			args:    args{in: "\x81\xa1a\xdb\x7f\xff\xff\xff"},
+			wantErr: true,
+		},
+		{
+			name:    "bin32 length header",
+			args:    args{in: "\x81\xa1a\xc6\x7f\xff\xff\xff"},
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			args:    args{in: ""},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := yare.ParseMsgPack([]byte(tt.args.in))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseMsgPack() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseMsgPack() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCBOR(t *testing.T) {
+	t.Parallel()
+
+	big, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+
+	type args struct {
+		in string
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		want    yare.Dict
+		wantErr bool
+	}{
+		{
+			name: "normal",
+			args: args{in: mustMarshal(t, cbor.Marshal, map[string]interface{}{
+				"foo":    "bar",
+				"list":   []interface{}{1, true, nil},
+				"bytes":  []byte("Hi"),
+				"time":   cbor.Tag{Number: 0, Content: testTime.Format(time.RFC3339Nano)},
+				"big":    big,
+				"tag":    cbor.Tag{Number: 42, Content: []byte("Hi")},
+				"nested": map[int]string{1: "one"},
+			})},
+			want: yare.Dict{
+				"foo":    "bar",
+				"list":   []interface{}{uint64(1), true, nil},
+				"bytes":  map[string]interface{}{"$binary": "SGk="},
+				"time":   "2021-02-06T12:30:00.0000005Z",
+				"big":    json.Number("123456789012345678901234567890"),
+				"tag":    map[string]interface{}{"$tag": uint64(42), "$value": map[string]interface{}{"$binary": "SGk="}},
+				"nested": map[string]interface{}{"1": "one"},
+			},
+		},
+		{
+			name:    "array",
+			args:    args{in: mustMarshal(t, cbor.Marshal, []int{1, 2})},
+			wantErr: true,
+		},
+		{
+			name:    "invalid",
+			args:    args{in: "\xa1\x63foo"},
+			wantErr: true,
+		},
+		{
+			name:    "array length header",
+			args:    args{in: "\xa1\x61a\x9b\x00\x00\x00\x00\x7f\xff\xff\xff"},
+			wantErr: true,
+		},
+		{
+			name:    "map length header",
+			args:    args{in: "\xbb\x00\x00\x00\x00\x7f\xff\xff\xff"},
+			wantErr: true,
+		},
+		{
+			name:    "byte string length header",
+			args:    args{in: "\xa1\x61a\x5b\x00\x00\x00\x00\x7f\xff\xff\xff"},
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			args:    args{in: ""},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := yare.ParseCBOR([]byte(tt.args.in))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseCBOR() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseCBOR() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBSON(t *testing.T) {
+	t.Parallel()
+
+	oid, _ := primitive.ObjectIDFromHex("5ffe8f3b4e1f2a3b4c5d6e7f")
+	dec, _ := primitive.ParseDecimal128("1.5")
+
+	type args struct {
+		in string
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		want    yare.Dict
+		wantErr bool
+	}{
+		{
+			name: "normal",
+			args: args{in: mustMarshal(t, bson.Marshal, bson.D{
+				{Key: "foo", Value: "bar"},
+				{Key: "int", Value: int32(1)},
+				{Key: "long", Value: int64(2)},
+				{Key: "double", Value: 1.5},
+				{Key: "list", Value: bson.A{true, nil}},
+				{Key: "nested", Value: bson.D{{Key: "foo", Value: "bar"}}},
+				{Key: "oid", Value: oid},
+				{Key: "bytes", Value: primitive.Binary{Subtype: 4, Data: []byte("Hi")}},
+				{Key: "time", Value: primitive.NewDateTimeFromTime(testTime)},
+				{Key: "regex", Value: primitive.Regex{Pattern: "^foo", Options: "i"}},
+				{Key: "ts", Value: primitive.Timestamp{T: 1, I: 2}},
+				{Key: "decimal", Value: dec},
+				{Key: "code", Value: primitive.JavaScript("x")},
+				{Key: "min", Value: primitive.MinKey{}},
+			})},
+			want: yare.Dict{
+				"foo":     "bar",
+				"int":     int32(1),
+				"long":    int64(2),
+				"double":  1.5,
+				"list":    []interface{}{true, nil},
+				"nested":  map[string]interface{}{"foo": "bar"},
+				"oid":     map[string]interface{}{"$oid": "5ffe8f3b4e1f2a3b4c5d6e7f"},
+				"bytes":   map[string]interface{}{"$binary": "SGk=", "$subtype": byte(4)},
+				"time":    "2021-02-06T12:30:00Z",
+				"regex":   map[string]interface{}{"$regex": "^foo", "$options": "i"},
+				"ts":      map[string]interface{}{"$timestamp": map[string]interface{}{"t": uint32(1), "i": uint32(2)}},
+				"decimal": map[string]interface{}{"$numberDecimal": "1.5"},
+				"code":    map[string]interface{}{"$code": "x"},
+				"min":     map[string]interface{}{"$minKey": 1},
+			},
+		},
+		{
+			name:    "invalid",
+			args:    args{in: "\x05\x00\x00\x00\x01"},
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			args:    args{in: ""},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := yare.ParseBSON([]byte(tt.args.in))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseBSON() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseBSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+)
+
+// bodyCapture is the replacement body of a truncated capture.
+// It serves the buffered prefix followed by the rest of the original stream and counts the bytes read.
+type bodyCapture struct {
+	reader io.Reader
+	closer io.Closer
+	count  int64
+}
+
+func (c *bodyCapture) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.count += int64(n)
+
+	return n, err
+}
+
+func (c *bodyCapture) Close() error {
+	return c.closer.Close()
+}
+
+// drain reads at most limit bytes of the rest of the body and returns the total number of bytes
+// in the original stream. The returned flag is false if the body is longer than the limit.
+func (c *bodyCapture) drain(limit int64) (int64, bool, error) {
+	n, err := io.Copy(ioutil.Discard, io.LimitReader(c, limit+1))
+	if err != nil {
+		return c.count, false, err
+	}
+
+	return c.count, n <= limit, nil
+}
+
+// captureReader reads at most max bytes from r, zero or negative max means no limit.
+//
+// The returned reader serves the full original content, the returned flag reports
+// whether the captured data is only a prefix of the content.
+func captureReader(r io.ReadCloser, max int64) (io.ReadCloser, []byte, bool, error) {
+	if max <= 0 {
+		reader, data, err := wrapReader(r)
+
+		return reader, data, false, err
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(r, max))
+	if err != nil {
+		r.Close()
+
+		return nil, nil, false, wrapError(err)
+	}
+
+	var next [1]byte
+
+	n, err := io.ReadFull(r, next[:])
+	if n == 0 {
+		r.Close()
+
+		if err != nil && err != io.EOF {
+			return nil, nil, false, wrapError(err)
+		}
+
+		return ioutil.NopCloser(bytes.NewBuffer(data)), data, false, nil
+	}
+
+	reader := io.MultiReader(bytes.NewReader(data), bytes.NewReader(next[:n]), r)
+
+	return &bodyCapture{reader: reader, closer: r}, data, true, nil
+}
+
+// mapCapture creates the capture section of a truncated body.
+// The size is the real content length if known, negative otherwise.
+func mapCapture(captured int, size int64) Dict {
+	out := Dict{"truncated": true, "captured": captured}
+
+	if size >= 0 {
+		out["size"] = size
+	}
+
+	return out
+}
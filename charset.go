@@ -0,0 +1,99 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+var errUnsupportedCharset = errors.New("unsupported charset")
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// decodeCharset converts content to UTF-8 according to charset media type parameter.
+//
+// Byte order mark is honored and removed for Unicode charsets.
+func decodeCharset(params map[string]string, content []byte) ([]byte, error) {
+	label, ok := params["charset"]
+	if !ok {
+		return content, nil
+	}
+
+	enc, err := lookupCharset(label)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	if enc == unicode.UTF8 {
+		return bytes.TrimPrefix(content, utf8BOM), nil
+	}
+
+	out, _, err := transform.Bytes(unicode.BOMOverride(enc.NewDecoder()), content)
+	if err != nil {
+		return nil, wrapError(fmt.Errorf("%s: %w", label, err))
+	}
+
+	return out, nil
+}
+
+func lookupCharset(label string) (encoding.Encoding, error) {
+	enc, err := htmlindex.Get(strings.TrimSpace(label))
+	if err != nil || enc == nil {
+		return nil, fmt.Errorf("%w: %s", errUnsupportedCharset, label)
+	}
+
+	return enc, nil
+}
+
+// charsetReader converts XML documents declaring non UTF-8 encoding.
+//
+// Content already converted to UTF-8 (using charset media type parameter) is returned unchanged,
+// because charset media type parameter takes precedence over XML encoding declaration.
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	data, err := ioutil.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if utf8.Valid(data) {
+		return bytes.NewReader(data), nil
+	}
+
+	enc, err := lookupCharset(label)
+	if err != nil {
+		return nil, err
+	}
+
+	return transform.NewReader(bytes.NewReader(data), enc.NewDecoder()), nil
+}
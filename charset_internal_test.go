@@ -0,0 +1,62 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_decodeCharset(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		params  map[string]string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{name: "none", params: map[string]string{}, in: "\xe1rv\xedz", want: "\xe1rv\xedz"},
+		{name: "utf-8", params: map[string]string{"charset": "UTF-8"}, in: "\xef\xbb\xbfárvíz", want: "árvíz"},
+		{name: "latin2", params: map[string]string{"charset": "ISO-8859-2"}, in: "t\xfck\xf5r", want: "tükőr"},
+		{name: "utf-16 bom", params: map[string]string{"charset": "utf-16"}, in: "\xfe\xff\x00{\x00}", want: "{}"},
+		{name: "utf-16le", params: map[string]string{"charset": "utf-16le"}, in: "{\x00}\x00", want: "{}"},
+		{name: "unsupported", params: map[string]string{"charset": "dummy"}, in: "foo", wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := decodeCharset(tt.params, []byte(tt.in))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("decodeCharset() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+			if !tt.wantErr && !bytes.Equal(got, []byte(tt.want)) {
+				t.Errorf("decodeCharset() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
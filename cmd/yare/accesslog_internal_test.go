@@ -0,0 +1,112 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readLog(t *testing.T, path string) string {
+	t.Helper()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+func Test_rotatingFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "access.log")
+
+	_ = ioutil.WriteFile(path, []byte("old\n"), 0o600)
+
+	f, err := openRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{"one\n", "two\n", "three\n", "four\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{path: "four\n", path + ".1": "two\nthree\n", path + ".2": "old\none\n"}
+	for file, content := range want {
+		if got := readLog(t, file); got != content {
+			t.Errorf("%s = %q, want %q", filepath.Base(file), got, content)
+		}
+	}
+
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("%s.3 exists, want at most 2 backups", filepath.Base(path))
+	}
+}
+
+func Test_rotatingFileNoBackup(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "access.log")
+
+	f, err := openRotatingFile(path, 5, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	_, _ = f.Write([]byte("one\n"))
+	_, _ = f.Write([]byte("two\n"))
+
+	if got := readLog(t, path); got != "two\n" {
+		t.Errorf("access.log = %q, want %q", got, "two\n")
+	}
+}
+
+func Test_openAccessLog(t *testing.T) {
+	t.Parallel()
+
+	w, err := openAccessLog(&options{accessLog: "-"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := w.(nopWriteCloser); !ok {
+		t.Errorf("openAccessLog() = %T, want standard output", w)
+	}
+
+	if _, err := openAccessLog(&options{accessLog: filepath.Join(t.TempDir(), "missing", "access.log")}); err == nil {
+		t.Error("openAccessLog() error = nil, want error")
+	}
+}
@@ -0,0 +1,260 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/szkiba/yare"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	errConfigKey   = errors.New("unknown key")
+	errConfigValue = errors.New("value must be scalar or list of scalars")
+)
+
+const envPrefix = "YARE_"
+
+// configOnly flags can not be set from the configuration file and are not printed by -print-config.
+var configOnly = map[string]bool{"config": true, "print-config": true, "v": true}
+
+// configSecret flags are printed masked by -print-config.
+var configSecret = map[string]bool{"redact-salt": true}
+
+// envName returns the name of the environment variable of a flag, e.g. YARE_MAX_BODY for -max-body.
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// configure applies the environment variables and the configuration file to the flags not set on
+// the command line. Environment variables take precedence over the configuration file.
+// Repeatable flags are given as comma separated list in environment variables,
+// a comma inside a value is escaped by backslash (see splitList).
+func configure(flags *flag.FlagSet, lookupEnv func(string) (string, bool)) error {
+	explicit := make(map[string]bool)
+
+	flags.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	var source string
+
+	if !explicit["config"] {
+		if env, ok := lookupEnv(envName("config")); ok {
+			if err := flags.Set("config", env); err != nil {
+				return err
+			}
+		}
+	}
+
+	var file map[string]interface{}
+
+	if path := flags.Lookup("config").Value.String(); len(path) != 0 {
+		var err error
+
+		if file, err = readConfig(path); err != nil {
+			return err
+		}
+
+		source = path
+	}
+
+	for key := range file {
+		if f := flags.Lookup(key); f == nil || configOnly[key] {
+			return fmt.Errorf("%s: %s: %w", source, key, errConfigKey)
+		}
+	}
+
+	var err error
+
+	flags.VisitAll(func(f *flag.Flag) {
+		if err != nil || explicit[f.Name] || f.Name == "config" {
+			return
+		}
+
+		if env, ok := lookupEnv(envName(f.Name)); ok {
+			values := []string{env}
+			if repeatable(f.Value) {
+				values = splitList(env)
+			}
+
+			err = setValues(f, envName(f.Name), values)
+
+			return
+		}
+
+		if v, ok := file[f.Name]; ok {
+			var values []string
+
+			if values, err = configValues(v); err == nil {
+				err = setValues(f, source+": "+f.Name, values)
+			} else {
+				err = fmt.Errorf("%s: %s: %w", source, f.Name, err)
+			}
+		}
+	})
+
+	return err
+}
+
+// splitList splits a comma separated list, where \, stands for a comma inside an item
+// (e.g. query:/^a{1\,3}$/). Other backslashes are kept as is.
+func splitList(list string) []string {
+	values := []string{}
+
+	var sb strings.Builder
+
+	for i := 0; i < len(list); i++ {
+		switch {
+		case list[i] == '\\' && i+1 < len(list) && list[i+1] == ',':
+			sb.WriteByte(',')
+			i++
+		case list[i] == ',':
+			values = append(values, sb.String())
+			sb.Reset()
+		default:
+			sb.WriteByte(list[i])
+		}
+	}
+
+	return append(values, sb.String())
+}
+
+func setValues(f *flag.Flag, source string, values []string) error {
+	for _, value := range values {
+		if err := f.Value.Set(value); err != nil {
+			return fmt.Errorf("%s: invalid value %q: %w", source, value, err)
+		}
+	}
+
+	return nil
+}
+
+// readConfig reads JSON (.json extension) or YAML configuration file.
+func readConfig(path string) (map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]interface{})
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+
+		err = dec.Decode(&out)
+	} else {
+		err = yaml.Unmarshal(data, &out)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return out, nil
+}
+
+func configValues(v interface{}) ([]string, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		return nil, errConfigValue
+	case []interface{}:
+		values := make([]string, 0, len(v))
+
+		for _, item := range v {
+			switch item.(type) {
+			case nil, map[string]interface{}, []interface{}:
+				return nil, errConfigValue
+			}
+
+			values = append(values, fmt.Sprint(item))
+		}
+
+		return values, nil
+	default:
+		return []string{fmt.Sprint(v)}, nil
+	}
+}
+
+func repeatable(v flag.Value) bool {
+	switch v := v.(type) {
+	case *stringsFlag:
+		return true
+	case *checkedValue:
+		return repeatable(v.Value)
+	default:
+		return false
+	}
+}
+
+// writeConfig writes the effective configuration of o in YAML, usable as configuration file.
+// Secret values are masked, they have to be filled in before reusing the output.
+func writeConfig(w io.Writer, o *options) error {
+	flags := newFlagSet("config", o)
+	out := make(map[string]interface{})
+
+	flags.VisitAll(func(f *flag.Flag) {
+		switch {
+		case configOnly[f.Name]:
+		case configSecret[f.Name] && len(f.Value.String()) != 0:
+			out[f.Name] = yare.DefaultRedactMask
+		default:
+			out[f.Name] = configValue(f.Value)
+		}
+	})
+
+	enc := yaml.NewEncoder(w)
+	defer enc.Close()
+
+	return enc.Encode(out)
+}
+
+func configValue(v flag.Value) interface{} {
+	switch v := v.(type) {
+	case *stringsFlag:
+		return append([]string{}, *v...)
+	case *checkedValue:
+		return configValue(v.Value)
+	case flag.Getter:
+		if d, ok := v.Get().(time.Duration); ok {
+			return d.String()
+		}
+
+		return v.Get()
+	default:
+		return v.String()
+	}
+}
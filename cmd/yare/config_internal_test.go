@@ -0,0 +1,245 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/szkiba/yare"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+func Test_getoptConfig(t *testing.T) {
+	t.Parallel()
+
+	yamlFile := writeFile(t, "yare.yaml", `
+port: 1010
+max-body: 1024
+raw: true
+wire: hex
+read-timeout: 1m
+redact:
+  - query:key
+  - $.body.password
+`)
+	jsonFile := writeFile(t, "yare.json", `{"port": 1010, "max-body": 1024, "raw": true, "wire": "hex",
+"read-timeout": "1m", "redact": ["query:key", "$.body.password"]}`)
+
+	fromFile := func(file string) func(o *options) {
+		return func(o *options) {
+			o.config, o.port, o.maxBody, o.raw, o.wire, o.readTimeout = file, 1010, 1024, true, "hex", time.Minute
+			o.redact = stringsFlag{"query:key", "$.body.password"}
+		}
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want func(o *options)
+	}{
+		{name: "yaml", args: []string{"-config", yamlFile}, want: fromFile(yamlFile)},
+		{name: "json", args: []string{"-config", jsonFile}, want: fromFile(jsonFile)},
+		{name: "env config", env: map[string]string{"YARE_CONFIG": yamlFile}, want: fromFile(yamlFile)},
+		{
+			name: "precedence",
+			args: []string{"-config", yamlFile, "-port", "3030", "-redact", "headers:x-*"},
+			env:  map[string]string{"YARE_PORT": "2020", "YARE_MAX_BODY": "2048", "YARE_WIRE": "raw"},
+			want: func(o *options) {
+				fromFile(yamlFile)(o)
+				o.port, o.maxBody, o.wire, o.redact = 3030, 2048, "raw", stringsFlag{"headers:x-*"}
+			},
+		},
+		{
+			name: "env list",
+			env:  map[string]string{"YARE_REDACT": `query:/^a{1\,3}$/,headers:x-*,\d`},
+			want: func(o *options) {
+				o.redact = stringsFlag{"query:/^a{1,3}$/", "headers:x-*", `\d`}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			want := defaultOptions()
+			tt.want(want)
+
+			got, err := getopt(append([]string{"yare"}, tt.args...), lookupEnv(tt.env))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("getopt() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func Test_getoptConfigError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		env     map[string]string
+		want    string
+		is      error
+	}{
+		{
+			name: "unknown key", file: "yare.yaml", content: "prot: 1010\n",
+			want: "yare.yaml: prot: unknown key", is: errConfigKey,
+		},
+		{
+			name: "config key", file: "yare.yaml", content: "config: other.yaml\n",
+			want: "yare.yaml: config: unknown key",
+		},
+		{
+			name: "invalid int", file: "yare.yaml", content: "max-body: big\n",
+			want: "yare.yaml: max-body: invalid value \"big\"",
+		},
+		{
+			name: "invalid wire", file: "yare.json", content: `{"wire": "bin"}`,
+			want: "yare.json: wire: invalid value \"bin\"", is: yare.ErrWireFormat,
+		},
+		{
+			name: "invalid listen", file: "yare.yaml", content: "listen: [\"udp://:53\"]\n",
+			want: "yare.yaml: listen:", is: errListen,
+		},
+		{
+			name: "nested", file: "yare.yaml", content: "redact:\n  a: b\n",
+			want: "yare.yaml: redact:", is: errConfigValue,
+		},
+		{name: "syntax", file: "yare.json", content: `{"port": `, want: "yare.json: "},
+		{
+			name: "env", file: "yare.yaml", env: map[string]string{"YARE_RAW": "maybe"},
+			want: "YARE_RAW: invalid value \"maybe\"",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			file := writeFile(t, tt.file, tt.content)
+
+			_, err := getopt([]string{"yare", "-config", file}, lookupEnv(tt.env))
+			if err == nil {
+				t.Fatal("getopt() error = nil, want error")
+			}
+
+			msg := strings.TrimPrefix(err.Error(), filepath.Dir(file)+string(filepath.Separator))
+			if !strings.HasPrefix(msg, tt.want) {
+				t.Errorf("getopt() error = %s, want prefix %s", msg, tt.want)
+			}
+
+			if tt.is != nil && !errors.Is(err, tt.is) {
+				t.Errorf("getopt() error = %v, want %v", err, tt.is)
+			}
+		})
+	}
+}
+
+func Test_writeConfig(t *testing.T) {
+	t.Parallel()
+
+	o := defaultOptions()
+	o.port, o.wire, o.readTimeout, o.listen = 1010, "hex", time.Minute, stringsFlag{"tcp://:80", "unix:///run/yare.sock"}
+
+	var buff bytes.Buffer
+
+	if err := writeConfig(&buff, o); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := []string{"port: 1010\n", "wire: hex\n", "read-timeout: 1m0s\n", "max-body: 10485760\n", "raw: false\n"}
+
+	for _, line := range lines {
+		if !strings.Contains(buff.String(), line) {
+			t.Errorf("writeConfig() missing %q in\n%s", line, buff.String())
+		}
+	}
+
+	if strings.Contains(buff.String(), "print-config") {
+		t.Errorf("writeConfig() contains print-config:\n%s", buff.String())
+	}
+
+	file := writeFile(t, "yare.yaml", buff.String())
+
+	got, err := getopt([]string{"yare", "-config", file}, lookupEnv(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o.config = file
+
+	if !reflect.DeepEqual(got, o) {
+		t.Errorf("getopt() = %v, want %v", got, o)
+	}
+
+	buff.Reset()
+
+	if err := writeConfig(&buff, defaultOptions()); err != nil {
+		t.Fatal(err)
+	}
+
+	file = writeFile(t, "defaults.yaml", buff.String())
+
+	if _, err := getopt([]string{"yare", "-config", file}, lookupEnv(nil)); err != nil {
+		t.Errorf("getopt() error = %v with printed defaults", err)
+	}
+
+	buff.Reset()
+
+	o.redactSalt = "s3cret"
+
+	if err := writeConfig(&buff, o); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(buff.String(), o.redactSalt) || !strings.Contains(buff.String(), "redact-salt: '[REDACTED]'\n") {
+		t.Errorf("writeConfig() redact-salt not masked in\n%s", buff.String())
+	}
+}
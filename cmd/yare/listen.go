@@ -0,0 +1,211 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	errListen       = errors.New("listen address must be tcp://host:port, unix:///path, fd:// or fd://N")
+	errListenFDs    = errors.New("no inherited file descriptors (LISTEN_FDS)")
+	errListenFDsPID = errors.New("inherited file descriptors (LISTEN_FDS) belong to other process")
+)
+
+// listenFDsStart is the first file descriptor passed by systemd socket activation (SD_LISTEN_FDS_START).
+const listenFDsStart = 3
+
+// Schemes of the -listen flag.
+const (
+	schemeTCP  = "tcp"
+	schemeUnix = "unix"
+	schemeFD   = "fd"
+)
+
+// parseListen splits a -listen address into scheme and address.
+// The address of fd scheme is empty (all inherited file descriptors) or a file descriptor number.
+func parseListen(addr string) (string, string, error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %s", errListen, addr)
+	}
+
+	switch u.Scheme {
+	case schemeTCP:
+		if len(u.Host) != 0 && len(strings.Trim(u.Path, "/")) == 0 {
+			return u.Scheme, u.Host, nil
+		}
+	case schemeUnix:
+		if len(u.Host) == 0 && len(u.Path) != 0 {
+			return u.Scheme, u.Path, nil
+		}
+	case schemeFD:
+		if len(u.Path) != 0 {
+			break
+		}
+
+		if len(u.Host) == 0 {
+			return u.Scheme, "", nil
+		}
+
+		if fd, err := strconv.Atoi(u.Host); err == nil && fd >= listenFDsStart {
+			return u.Scheme, u.Host, nil
+		}
+	}
+
+	return "", "", fmt.Errorf("%w: %s", errListen, addr)
+}
+
+func validListen(addr string) error {
+	_, _, err := parseListen(addr)
+
+	return err
+}
+
+// listen creates listeners for the -listen addresses.
+// Without addresses the inherited file descriptors are used if any, otherwise TCP port is listened on.
+func listen(addrs []string, port int) ([]net.Listener, error) {
+	if len(addrs) == 0 {
+		if len(os.Getenv("LISTEN_FDS")) != 0 {
+			addrs = []string{schemeFD + "://"}
+		} else {
+			addrs = []string{fmt.Sprintf("%s://:%d", schemeTCP, port)}
+		}
+	}
+
+	var all []net.Listener
+
+	for _, addr := range addrs {
+		ls, err := listenOne(addr)
+		if err != nil {
+			for _, l := range all {
+				l.Close()
+			}
+
+			return nil, err
+		}
+
+		all = append(all, ls...)
+	}
+
+	return all, nil
+}
+
+func listenOne(addr string) ([]net.Listener, error) {
+	scheme, address, err := parseListen(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	switch scheme {
+	case schemeUnix:
+		removeStaleSocket(address)
+
+		l, err := net.Listen(scheme, address)
+		if err != nil {
+			return nil, err
+		}
+
+		return []net.Listener{l}, nil
+	case schemeFD:
+		return inheritedListeners(address)
+	default:
+		l, err := net.Listen(scheme, address)
+		if err != nil {
+			return nil, err
+		}
+
+		return []net.Listener{l}, nil
+	}
+}
+
+// removeStaleSocket removes the socket file left behind by a previous run.
+// Sockets still accepting connections and other kind of files are left untouched, so listening on them fails.
+func removeStaleSocket(path string) {
+	info, err := os.Lstat(path)
+	if err != nil || info.Mode()&os.ModeSocket == 0 {
+		return
+	}
+
+	if c, err := net.Dial(schemeUnix, path); err == nil {
+		c.Close()
+
+		return
+	}
+
+	_ = os.Remove(path)
+}
+
+// inheritedListeners returns listeners for the file descriptors passed by systemd socket activation.
+// If fd is not empty, only the given file descriptor is used.
+func inheritedListeners(fd string) ([]net.Listener, error) {
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, errListenFDs
+	}
+
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err == nil && pid != os.Getpid() {
+		return nil, errListenFDsPID
+	}
+
+	if len(fd) == 0 {
+		return fileListeners(listenFDsStart, count)
+	}
+
+	n, _ := strconv.Atoi(fd)
+	if n >= listenFDsStart+count {
+		return nil, fmt.Errorf("%w: %d", errListenFDs, n)
+	}
+
+	return fileListeners(n, 1)
+}
+
+func fileListeners(start, count int) ([]net.Listener, error) {
+	ls := make([]net.Listener, 0, count)
+
+	for fd := start; fd < start+count; fd++ {
+		f := os.NewFile(uintptr(fd), "fd"+strconv.Itoa(fd))
+
+		l, err := net.FileListener(f)
+
+		f.Close()
+
+		if err != nil {
+			for _, l := range ls {
+				l.Close()
+			}
+
+			return nil, fmt.Errorf("fd %d: %w", fd, err)
+		}
+
+		ls = append(ls, l)
+	}
+
+	return ls, nil
+}
@@ -0,0 +1,178 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func Test_parseListen(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		addr    string
+		scheme  string
+		address string
+		wantErr bool
+	}{
+		{addr: "tcp://:8080", scheme: "tcp", address: ":8080"},
+		{addr: "tcp://127.0.0.1:8080", scheme: "tcp", address: "127.0.0.1:8080"},
+		{addr: "tcp://[::1]:8080", scheme: "tcp", address: "[::1]:8080"},
+		{addr: "unix:///run/yare.sock", scheme: "unix", address: "/run/yare.sock"},
+		{addr: "fd://", scheme: "fd"},
+		{addr: "fd://3", scheme: "fd", address: "3"},
+		{addr: "fd://2", wantErr: true},
+		{addr: "fd://foo", wantErr: true},
+		{addr: "tcp://", wantErr: true},
+		{addr: "tcp://:8080/path", wantErr: true},
+		{addr: "unix://run/yare.sock", wantErr: true},
+		{addr: "udp://:8080", wantErr: true},
+		{addr: ":8080", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.addr, func(t *testing.T) {
+			t.Parallel()
+
+			scheme, address, err := parseListen(tt.addr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseListen() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil && !errors.Is(err, errListen) {
+				t.Errorf("parseListen() error = %v, want %v", err, errListen)
+			}
+
+			if scheme != tt.scheme || address != tt.address {
+				t.Errorf("parseListen() = %s, %s, want %s, %s", scheme, address, tt.scheme, tt.address)
+			}
+		})
+	}
+}
+
+func Test_listenStaleSocket(t *testing.T) {
+	t.Parallel()
+
+	sock := filepath.Join(t.TempDir(), "yare.sock")
+
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+
+	ls, err := listen([]string{"unix://" + sock}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ls[0].Close()
+}
+
+func Test_listen(t *testing.T) {
+	t.Parallel()
+
+	sock := filepath.Join(t.TempDir(), "yare.sock")
+
+	ls, err := listen([]string{"tcp://127.0.0.1:0", "unix://" + sock}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		for _, l := range ls {
+			l.Close()
+		}
+	}()
+
+	if len(ls) != 2 {
+		t.Fatalf("listen() returned %d listeners, want 2", len(ls))
+	}
+
+	if got := ls[0].Addr().Network(); got != "tcp" {
+		t.Errorf("network = %s, want tcp", got)
+	}
+
+	if got := ls[1].Addr().String(); got != sock {
+		t.Errorf("address = %s, want %s", got, sock)
+	}
+
+	if _, err := listen([]string{"unix://" + sock}, 0); err == nil {
+		t.Error("listen() error = nil, want address in use")
+	}
+}
+
+func Test_fileListeners(t *testing.T) {
+	t.Parallel()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer l.Close()
+
+	f, err := l.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ls, err := fileListeners(int(f.Fd()), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer ls[0].Close()
+
+	if got := ls[0].Addr().String(); got != l.Addr().String() {
+		t.Errorf("address = %s, want %s", got, l.Addr())
+	}
+}
+
+func Test_inheritedListeners(t *testing.T) {
+	t.Setenv("LISTEN_FDS", "")
+
+	if _, err := inheritedListeners(""); !errors.Is(err, errListenFDs) {
+		t.Errorf("inheritedListeners() error = %v, want %v", err, errListenFDs)
+	}
+
+	t.Setenv("LISTEN_FDS", "1")
+	t.Setenv("LISTEN_PID", "1")
+
+	if _, err := inheritedListeners(""); !errors.Is(err, errListenFDsPID) {
+		t.Errorf("inheritedListeners() error = %v, want %v", err, errListenFDsPID)
+	}
+
+	t.Setenv("LISTEN_PID", "")
+
+	if _, err := inheritedListeners(strconv.Itoa(listenFDsStart + 1)); !errors.Is(err, errListenFDs) {
+		t.Errorf("inheritedListeners() error = %v, want %v", err, errListenFDs)
+	}
+}
@@ -23,61 +23,426 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"runtime"
 	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/szkiba/yare"
 )
 
 var version = "dev"
 
+var errLogFormat = errors.New("format must be json, common or combined")
+
 type options struct {
-	port    int
-	version bool
+	config      string
+	printConfig bool
+
+	port       int
+	version    bool
+	jwks       string
+	rawMax     int
+	maxDecoded int64
+	maxBody    int64
+	raw        bool
+	wire       string
+	protocol   string
+	listen     stringsFlag
+
+	readTimeout       time.Duration
+	readHeaderTimeout time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+	drainTimeout      time.Duration
+	readyDelay        time.Duration
+	healthPrefix      string
+
+	accessLog           string
+	accessLogFormat     string
+	accessLogMaxSize    int64
+	accessLogMaxBackups int
+
+	tlsCert       string
+	tlsKey        string
+	tlsSelfSigned bool
+	clientCA      string
+	clientAuth    string
+
+	redact         stringsFlag
+	redactDefaults bool
+	redactMask     string
+	redactSalt     string
+}
+
+// stringsFlag is a repeatable string flag.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+
+	return nil
+}
+
+// stringValue is a string flag value for use with checkedValue.
+type stringValue string
+
+func (s *stringValue) String() string {
+	return string(*s)
+}
+
+func (s *stringValue) Set(value string) error {
+	*s = stringValue(value)
+
+	return nil
+}
+
+// checkedValue is a flag value which is validated before set.
+type checkedValue struct {
+	flag.Value
+	check func(string) error
+}
+
+func (v *checkedValue) String() string {
+	if v.Value == nil {
+		return ""
+	}
+
+	return v.Value.String()
+}
+
+func (v *checkedValue) Set(value string) error {
+	if err := v.check(value); err != nil {
+		return err
+	}
+
+	return v.Value.Set(value)
 }
 
-const defaultPort = 8080
+const (
+	defaultPort    = 8080
+	defaultMaxBody = 10 * 1024 * 1024
+
+	defaultAccessLogMaxSize    = 100 * 1024 * 1024
+	defaultAccessLogMaxBackups = 5
+)
 
-func getopt(args []string) *options {
-	flags := flag.NewFlagSet(args[0], flag.ExitOnError)
+func newOptions() *options {
+	return &options{
+		port:       defaultPort,
+		rawMax:     yare.DefaultRawBodyMax,
+		maxDecoded: yare.DefaultMaxDecodedSize,
+		maxBody:    defaultMaxBody,
+		redactMask: yare.DefaultRedactMask,
 
-	o := options{port: defaultPort}
+		readHeaderTimeout: defaultReadHeaderTimeout,
+		idleTimeout:       defaultIdleTimeout,
+		drainTimeout:      defaultDrainTimeout,
+		healthPrefix:      defaultHealthPrefix,
 
-	if p, err := strconv.Atoi(os.Getenv("PORT")); err == nil {
-		o.port = p
+		accessLogFormat:     yare.LogJSON,
+		accessLogMaxSize:    defaultAccessLogMaxSize,
+		accessLogMaxBackups: defaultAccessLogMaxBackups,
 	}
+}
+
+// newFlagSet creates the command line flags bound to the fields of o.
+func newFlagSet(name string, o *options) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+
+	flags.StringVar(&o.config, "config", o.config, "configuration file (YAML or JSON), keys are the flag names")
+	flags.BoolVar(&o.printConfig, "print-config", o.printConfig, "prints the effective configuration and exits")
+
+	flags.IntVar(&o.port, "port", o.port, "port to listen on (if no -listen given)")
+	flags.Var(&checkedValue{Value: &o.listen, check: validListen}, "listen",
+		"listen address, tcp://host:port, unix:///path, fd:// or fd://N for inherited (LISTEN_FDS)\n"+
+			"file descriptors (repeatable, default tcp://:port or fd:// with socket activation)")
+	flags.StringVar(&o.jwks, "jwks", o.jwks, "JWKS file or URL for JWT signature verification")
+	flags.IntVar(&o.rawMax, "raw-max", o.rawMax, "maximum number of unparsed body bytes to echo, 0 disables")
+	flags.Int64Var(&o.maxDecoded, "max-decoded", o.maxDecoded, "maximum size of decompressed body")
+	flags.Int64Var(&o.maxBody, "max-body", o.maxBody, "maximum number of body bytes to buffer, 0 means no limit")
 
-	flags.IntVar(&o.port, "port", o.port, "port to listen on")
+	flags.BoolVar(&o.raw, "raw", o.raw, "echo every header field and cookie occurrence as seen on the wire")
+	flags.Var(&checkedValue{Value: (*stringValue)(&o.wire), check: validWire}, "wire",
+		"echo the request bytes as seen on the wire in given format: raw, hex or escaped")
+	flags.Var(&checkedValue{Value: (*stringValue)(&o.protocol), check: validProtocol}, "protocol",
+		"serve only given HTTP version: 1.0, 1.1 or 2 (default any, including h2c)")
+	flags.DurationVar(&o.readTimeout, "read-timeout", o.readTimeout,
+		"maximum duration of reading the request, 0 means no limit")
+	flags.DurationVar(&o.readHeaderTimeout, "read-header-timeout", o.readHeaderTimeout,
+		"maximum duration of reading the request header, 0 means -read-timeout")
+	flags.DurationVar(&o.writeTimeout, "write-timeout", o.writeTimeout,
+		"maximum duration of writing the response, 0 means no limit")
+	flags.DurationVar(&o.idleTimeout, "idle-timeout", o.idleTimeout,
+		"maximum duration of waiting for the next request on keep-alive connections, 0 means -read-timeout")
+	flags.DurationVar(&o.drainTimeout, "drain-timeout", o.drainTimeout,
+		"maximum duration of waiting for active connections on SIGTERM or SIGINT")
+	flags.DurationVar(&o.readyDelay, "ready-delay", o.readyDelay,
+		"duration between failing the readyz endpoint and closing the listeners on SIGTERM or SIGINT")
+	flags.Var(&checkedValue{Value: (*stringValue)(&o.healthPrefix), check: validHealthPrefix}, "health-prefix",
+		"path prefix of the healthz and readyz endpoints")
+	flags.StringVar(&o.accessLog, "access-log", o.accessLog, "access log file, - means standard output (default disabled)")
+	flags.Var(&checkedValue{Value: (*stringValue)(&o.accessLogFormat), check: validLogFormat}, "access-log-format",
+		"access log format: json, common or combined")
+	flags.Int64Var(&o.accessLogMaxSize, "access-log-max-size", o.accessLogMaxSize,
+		"maximum size of access log file before rotation, 0 disables rotation")
+	flags.IntVar(&o.accessLogMaxBackups, "access-log-max-backups", o.accessLogMaxBackups,
+		"maximum number of rotated access log files to keep")
+	flags.StringVar(&o.tlsCert, "tls-cert", o.tlsCert, "TLS certificate file (PEM)")
+	flags.StringVar(&o.tlsKey, "tls-key", o.tlsKey, "TLS private key file (PEM)")
+	flags.BoolVar(&o.tlsSelfSigned, "tls-self-signed", o.tlsSelfSigned, "serve TLS with in-memory self-signed certificate")
+	flags.StringVar(&o.clientCA, "client-ca", o.clientCA, "CA certificates file (PEM) for client certificate verification")
+	flags.StringVar(&o.clientAuth, "client-auth", o.clientAuth,
+		"client certificate policy: none, request, require, verify-if-given or require-and-verify\n"+
+			"(default verify-if-given with -client-ca, none otherwise, verifying policies require -client-ca)")
+	flags.Var(&o.redact, "redact", "redaction rule, section:glob, section:/regexp/ or $.json.path (repeatable)")
+	flags.BoolVar(&o.redactDefaults, "redact-defaults", o.redactDefaults, "redact commonly sensitive values")
+	flags.StringVar(&o.redactMask, "redact-mask", o.redactMask, "replacement of redacted values")
+	flags.StringVar(&o.redactSalt, "redact-salt", o.redactSalt, "replace redacted values with HMAC-SHA256 hash using this salt")
 
-	ver := flags.Bool("v", false, "prints version")
+	flags.BoolVar(&o.version, "v", o.version, "prints version")
+
+	return flags
+}
+
+// getopt parses the command line, then applies the YARE_* environment variables and
+// the configuration file for flags not given on the command line.
+// The legacy PORT environment variable has the lowest precedence.
+func getopt(args []string, lookupEnv func(string) (string, bool)) (*options, error) {
+	o := newOptions()
+
+	if env, ok := lookupEnv("PORT"); ok {
+		if p, err := strconv.Atoi(env); err == nil {
+			o.port = p
+		}
+	}
+
+	flags := newFlagSet(args[0], o)
 
 	_ = flags.Parse(args[1:])
 
-	o.version = *ver
+	if err := configure(flags, lookupEnv); err != nil {
+		return nil, err
+	}
 
-	return &o
+	return o, nil
+}
+
+func validLogFormat(format string) error {
+	switch format {
+	case yare.LogJSON, yare.LogCommon, yare.LogCombined:
+		return nil
+	default:
+		return errLogFormat
+	}
+}
+
+func validWire(format string) error {
+	switch format {
+	case "", yare.WireRaw, yare.WireHex, yare.WireEscaped:
+		return nil
+	default:
+		return yare.ErrWireFormat
+	}
 }
 
 func main() {
-	o := getopt(os.Args)
+	o, err := getopt(os.Args, os.LookupEnv)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	if o.version {
 		fmt.Fprintf(os.Stderr, "yare/%s %s/%s\n", version, runtime.GOOS, runtime.GOARCH)
 		os.Exit(0)
 	}
 
-	http.Handle("/", yare.EchoHander(true))
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", o.port), nil))
+	if o.printConfig {
+		if err := writeConfig(os.Stdout, o); err != nil {
+			log.Fatal(err)
+		}
+
+		os.Exit(0)
+	}
+
+	m, err := newMapper(o)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	r, err := newRedactor(o)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	opts := []yare.Option{yare.WithBody(true), yare.WithConnection(true), yare.WithRaw(o.raw), yare.WithWire(o.wire)}
+	if r != nil {
+		opts = append(opts, yare.WithRedactor(r))
+	}
+
+	cfg, err := newTLSConfig(o)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ls, err := listen(o.listen, o.port)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var (
+		handler   = m.EchoHandlerWithOptions(opts...)
+		accessLog io.WriteCloser
+	)
+
+	if len(o.accessLog) != 0 {
+		if accessLog, err = openAccessLog(o); err != nil {
+			log.Fatal(err)
+		}
+
+		// the same options let the echo handler reuse the request mapping of the access log
+		if handler, err = m.AccessLogHandler(handler, accessLog, o.accessLogFormat, opts...); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	srv := newServer(o, cfg)
+	h := new(health)
+
+	// health endpoints are served regardless of -protocol and not logged in the access log
+	h.register(http.DefaultServeMux, o.healthPrefix)
+
+	if srv.Handler, err = configureProtocol(srv, o.protocol, http.DefaultServeMux, handler); err != nil {
+		log.Fatal(err)
+	}
+
+	if cfg != nil && (o.raw || len(o.wire) != 0) {
+		log.Print("wire level capture is not available with TLS")
+	}
+
+	if o.tlsSelfSigned {
+		log.Printf("self-signed certificate SHA-256 fingerprint: %s", fingerprint(cfg.Certificates[0]))
+	}
+
+	wire := o.raw || len(o.wire) != 0
+	errc := make(chan error, len(ls))
+
+	for _, l := range ls {
+		log.Printf("listening on %s://%s", l.Addr().Network(), l.Addr())
+
+		go func(l net.Listener) {
+			l = h.track(l)
+
+			if cfg != nil {
+				errc <- srv.ServeTLS(l, "", "")
+
+				return
+			}
+
+			if wire {
+				l = yare.WireListener(l, 0)
+			}
+
+			errc <- srv.Serve(l)
+		}(l)
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGTERM, syscall.SIGINT)
+
+	select {
+	case err := <-errc:
+		log.Fatal(err)
+	case s := <-sig:
+		log.Printf("%s received, shutting down", s)
+	}
+
+	if err := h.shutdown(srv, o.readyDelay, o.drainTimeout); err != nil {
+		log.Fatal(err)
+	}
+
+	if accessLog != nil {
+		_ = accessLog.Close()
+	}
+}
+
+func newMapper(o *options) (*yare.Mapper, error) {
+	m := yare.NewMapper()
+	m.MaxDecodedSize = o.maxDecoded
+	m.MaxBodySize = o.maxBody
+
+	if o.rawMax > 0 {
+		m.Fallback = yare.RawBody(o.rawMax)
+	}
+
+	parseJWT := yare.ParseJWT
+
+	if len(o.jwks) != 0 {
+		keys := yare.NewKeySet()
+		if err := keys.LoadJWKS(o.jwks); err != nil {
+			return nil, err
+		}
+
+		parseJWT = keys.ParseJWT
+	}
+
+	// NDJSON must precede JSON, otherwise JSON parser would match by suffix
+	_ = m.RegisterContentType("application/x-ndjson", yare.ParseNDJSON)
+	_ = m.RegisterContentType("application/jsonl", yare.ParseNDJSON)
+	_ = m.RegisterContentType("application/json", yare.ParseJSON)
+	_ = m.RegisterContentType("application/jwt", parseJWT)
+	_ = m.RegisterContentType("application/xml", yare.ParseXML)
+	_ = m.RegisterContentType("text/xml", yare.ParseXML)
+	_ = m.RegisterContentType("*/*+xml", yare.ParseXML)
+	_ = m.RegisterContentType("application/yaml", yare.ParseYAML)
+	_ = m.RegisterContentType("text/yaml", yare.ParseYAML)
+	_ = m.RegisterContentType("application/toml", yare.ParseTOML)
+	_ = m.RegisterContentType("application/msgpack", yare.ParseMsgPack)
+	_ = m.RegisterContentType("application/cbor", yare.ParseCBOR)
+	_ = m.RegisterContentType("application/bson", yare.ParseBSON)
+
+	m.RegisterAuthScheme("Bearer", yare.ParserFunc(parseJWT).Optional())
+	m.RegisterAuthScheme("Basic", yare.ParserFunc(yare.ParseBasic).Optional())
+
+	return m, nil
 }
 
-func init() {
-	_ = yare.RegisterContentType("application/json", yare.ParseJSON)
-	_ = yare.RegisterContentType("application/jwt", yare.ParseJWT)
+func newRedactor(o *options) (*yare.Redactor, error) {
+	if !o.redactDefaults && len(o.redact) == 0 {
+		return nil, nil
+	}
+
+	r := yare.NewRedactor()
+	r.Mask = o.redactMask
+
+	if len(o.redactSalt) != 0 {
+		r.Hash = true
+		r.Salt = []byte(o.redactSalt)
+	}
+
+	if o.redactDefaults {
+		r.AddDefaultRules()
+	}
+
+	for _, spec := range o.redact {
+		if err := r.AddRule(spec); err != nil {
+			return nil, err
+		}
+	}
 
-	yare.RegisterAuthScheme("Bearer", yare.ParserFunc(yare.ParseJWT).Optional())
+	return r, nil
 }
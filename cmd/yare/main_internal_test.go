@@ -23,40 +23,344 @@
 package main
 
 import (
+	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/szkiba/yare"
 )
 
+func defaultOptions() *options {
+	return &options{
+		port: 8080, rawMax: 65536, maxDecoded: 33554432, maxBody: 10485760, redactMask: "[REDACTED]",
+		readHeaderTimeout: 10 * time.Second, idleTimeout: 2 * time.Minute, drainTimeout: 20 * time.Second,
+		healthPrefix: "/.yare", accessLogFormat: "json", accessLogMaxSize: 104857600, accessLogMaxBackups: 5,
+	}
+}
+
+func lookupEnv(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := env[key]
+
+		return v, ok
+	}
+}
+
 func Test_getopt(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
 		name string
 		args []string
-		want *options
+		env  map[string]string
+		want func(o *options)
 	}{
 		{
 			name: "defaults",
-			want: &options{port: 8080},
+			want: func(o *options) {},
+		},
+		{
+			name: "port env",
+			want: func(o *options) { o.port = 1010 },
+			env:  map[string]string{"PORT": "1010"},
+		},
+		{
+			name: "yare env",
+			want: func(o *options) { o.port, o.maxBody, o.listen = 2020, 0, stringsFlag{"tcp://:80", "tcp://:81"} },
+			env: map[string]string{
+				"PORT": "1010", "YARE_PORT": "2020", "YARE_MAX_BODY": "0", "YARE_LISTEN": "tcp://:80,tcp://:81",
+			},
+		},
+		{
+			name: "flag over env",
+			want: func(o *options) { o.port = 3030 },
+			args: []string{"-port", "3030"},
+			env:  map[string]string{"YARE_PORT": "2020"},
 		},
 		{
 			name: "port",
-			want: &options{port: 1010},
+			want: func(o *options) { o.port = 1010 },
 			args: []string{"-port", "1010"},
 		},
 		{
 			name: "version",
-			want: &options{port: 8080, version: true},
+			want: func(o *options) { o.version = true },
 			args: []string{"-v"},
 		},
+		{
+			name: "raw-max",
+			want: func(o *options) { o.rawMax = 0 },
+			args: []string{"-raw-max", "0"},
+		},
+		{
+			name: "max-decoded",
+			want: func(o *options) { o.maxDecoded = 1024 },
+			args: []string{"-max-decoded", "1024"},
+		},
+		{
+			name: "max-body",
+			want: func(o *options) { o.maxBody = 0 },
+			args: []string{"-max-body", "0"},
+		},
+		{
+			name: "raw",
+			want: func(o *options) { o.raw = true },
+			args: []string{"-raw"},
+		},
+		{
+			name: "wire",
+			want: func(o *options) { o.wire = "hex" },
+			args: []string{"-wire", "hex"},
+		},
+		{
+			name: "listen",
+			want: func(o *options) { o.listen = stringsFlag{"tcp://:80", "unix:///run/yare.sock"} },
+			args: []string{"-listen", "tcp://:80", "-listen", "unix:///run/yare.sock"},
+		},
+		{
+			name: "timeouts",
+			want: func(o *options) {
+				o.readTimeout, o.readHeaderTimeout, o.writeTimeout = time.Minute, time.Second, 2*time.Minute
+				o.idleTimeout, o.drainTimeout, o.readyDelay = 0, time.Hour, 5*time.Second
+			},
+			args: []string{
+				"-read-timeout", "1m", "-read-header-timeout", "1s", "-write-timeout", "2m",
+				"-idle-timeout", "0", "-drain-timeout", "1h", "-ready-delay", "5s",
+			},
+		},
+		{
+			name: "health-prefix",
+			want: func(o *options) { o.healthPrefix = "/_" },
+			args: []string{"-health-prefix", "/_"},
+		},
+		{
+			name: "access-log",
+			want: func(o *options) {
+				o.accessLog, o.accessLogFormat, o.accessLogMaxSize, o.accessLogMaxBackups = "-", "combined", 0, 1
+			},
+			args: []string{
+				"-access-log", "-", "-access-log-format", "combined",
+				"-access-log-max-size", "0", "-access-log-max-backups", "1",
+			},
+		},
+		{
+			name: "protocol",
+			want: func(o *options) { o.protocol = "2" },
+			args: []string{"-protocol", "2"},
+		},
+		{
+			name: "tls",
+			want: func(o *options) {
+				o.tlsCert, o.tlsKey, o.clientCA, o.clientAuth = "cert.pem", "key.pem", "ca.pem", "require"
+			},
+			args: []string{"-tls-cert", "cert.pem", "-tls-key", "key.pem", "-client-ca", "ca.pem", "-client-auth", "require"},
+		},
+		{
+			name: "tls-self-signed",
+			want: func(o *options) { o.tlsSelfSigned = true },
+			args: []string{"-tls-self-signed"},
+		},
+		{
+			name: "jwks",
+			want: func(o *options) { o.jwks = "jwks.json" },
+			args: []string{"-jwks", "jwks.json"},
+		},
+		{
+			name: "redact",
+			want: func(o *options) {
+				o.redact = stringsFlag{"query:key", "$.body.password"}
+				o.redactDefaults = true
+				o.redactMask = "***"
+				o.redactSalt = "salt"
+			},
+			args: []string{
+				"-redact", "query:key", "-redact", "$.body.password",
+				"-redact-defaults", "-redact-mask", "***", "-redact-salt", "salt",
+			},
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			if got := getopt(append([]string{"yare"}, tt.args...)); !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("getopt() = %v, want %v", got, tt.want)
+			want := defaultOptions()
+			tt.want(want)
+			got, err := getopt(append([]string{"yare"}, tt.args...), lookupEnv(tt.env))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("getopt() = %v, want %v", got, want)
 			}
 		})
 	}
 }
+
+func Test_newMapper(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		auth string
+		want yare.Dict
+	}{
+		{
+			name: "basic",
+			auth: "Basic QWxhZGRpbjpvcGVuIHNlc2FtZQ==",
+			want: yare.Dict{"Basic": yare.Dict{"username": "Aladdin", "password": "open sesame"}},
+		},
+		{
+			name: "malformed basic",
+			auth: "Basic invalid",
+			want: yare.Dict{"Basic": "invalid"},
+		},
+		{
+			name: "malformed bearer",
+			auth: "Bearer invalid",
+			want: yare.Dict{"Bearer": "invalid"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := httptest.NewRequest("GET", "http://localhost/", nil)
+			r.Header.Set("Authorization", tt.auth)
+
+			m, err := newMapper(&options{})
+			if err != nil {
+				t.Errorf("newMapper() error = %v", err)
+
+				return
+			}
+
+			got, err := m.MapRequest(r, false)
+			if err != nil {
+				t.Errorf("MapRequest() error = %v", err)
+
+				return
+			}
+			if !reflect.DeepEqual(got["authorization"], tt.want) {
+				t.Errorf("MapRequest() authorization = %v, want %v", got["authorization"], tt.want)
+			}
+		})
+	}
+}
+
+func Test_newMapperContentTypes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		cty  string
+		body string
+		want interface{}
+	}{
+		{cty: "application/json", body: `{"foo":"bar"}`, want: yare.Dict{"foo": "bar"}},
+		{cty: "application/x-ndjson", body: "{}\n{}\n", want: yare.Dict{"records": []interface{}{yare.Dict{}, yare.Dict{}}}},
+		{cty: "application/jsonl", body: "{}\n", want: yare.Dict{"records": []interface{}{yare.Dict{}}}},
+		{cty: "application/xml", body: "<foo>bar</foo>", want: yare.Dict{"foo": "bar"}},
+		{cty: "text/xml", body: "<foo>bar</foo>", want: yare.Dict{"foo": "bar"}},
+		{cty: "image/svg+xml", body: "<svg/>", want: yare.Dict{"svg": ""}},
+		{cty: "application/yaml", body: "foo: bar", want: yare.Dict{"foo": "bar"}},
+		{cty: "text/yaml", body: "foo: bar", want: yare.Dict{"foo": "bar"}},
+		{cty: "application/toml", body: `foo = "bar"`, want: yare.Dict{"foo": "bar"}},
+		{cty: "application/msgpack", body: "\x81\xa3foo\xa3bar", want: yare.Dict{"foo": "bar"}},
+		{cty: "application/x-msgpack", body: "\x81\xa3foo\xa3bar", want: yare.Dict{"foo": "bar"}},
+		{cty: "application/cbor", body: "\xa1\x63foo\x63bar", want: yare.Dict{"foo": "bar"}},
+		{cty: "application/bson", body: "\x12\x00\x00\x00\x02foo\x00\x04\x00\x00\x00bar\x00\x00", want: yare.Dict{"foo": "bar"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.cty, func(t *testing.T) {
+			t.Parallel()
+			r := httptest.NewRequest("POST", "http://localhost/", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.cty)
+
+			m, _ := newMapper(&options{})
+
+			got, err := m.MapRequest(r, true)
+			if err != nil {
+				t.Errorf("MapRequest() error = %v", err)
+
+				return
+			}
+
+			if !reflect.DeepEqual(normalize(got["body"]), normalize(tt.want)) {
+				t.Errorf("MapRequest() body = %v, want %v", got["body"], tt.want)
+			}
+		})
+	}
+}
+
+// normalize makes Dict and map[string]interface{} values comparable.
+func normalize(v interface{}) interface{} {
+	switch t := v.(type) {
+	case yare.Dict:
+		return normalize(map[string]interface{}(t))
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			out[k] = normalize(e)
+		}
+
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, e := range t {
+			out[i] = normalize(e)
+		}
+
+		return out
+	default:
+		return v
+	}
+}
+
+func Test_newMapperError(t *testing.T) {
+	t.Parallel()
+
+	if _, err := newMapper(&options{jwks: "missing.json"}); err == nil {
+		t.Error("newMapper() error is nil")
+	}
+}
+
+func Test_newRedactor(t *testing.T) {
+	t.Parallel()
+
+	if r, err := newRedactor(defaultOptions()); err != nil || r != nil {
+		t.Errorf("newRedactor() = %v, %v, want nil", r, err)
+	}
+
+	o := defaultOptions()
+	o.redactDefaults = true
+	o.redact = stringsFlag{"query:foo"}
+
+	r, err := newRedactor(o)
+	if err != nil {
+		t.Errorf("newRedactor() error = %v", err)
+
+		return
+	}
+
+	out := yare.Dict{
+		"headers": yare.Dict{"Authorization": "Basic dummy"},
+		"query":   yare.Dict{"foo": "bar", "baz": "qux"},
+	}
+
+	r.Redact(out)
+
+	want := yare.Dict{
+		"headers": yare.Dict{"Authorization": "[REDACTED]"},
+		"query":   yare.Dict{"foo": "[REDACTED]", "baz": "qux"},
+	}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("Redactor.Redact() = %v, want %v", out, want)
+	}
+
+	o.redact = stringsFlag{"dummy"}
+	if _, err := newRedactor(o); err == nil {
+		t.Error("newRedactor() error is nil")
+	}
+}
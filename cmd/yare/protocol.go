@@ -0,0 +1,111 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+
+	"github.com/szkiba/yare"
+	"golang.org/x/net/http2"
+)
+
+var errProtocol = errors.New("protocol must be 1.0, 1.1 or 2")
+
+// Values of the -protocol flag, empty means any supported protocol.
+const (
+	protocolHTTP10 = "1.0"
+	protocolHTTP11 = "1.1"
+	protocolHTTP2  = "2"
+)
+
+func validProtocol(proto string) error {
+	switch proto {
+	case "", protocolHTTP10, protocolHTTP11, protocolHTTP2:
+		return nil
+	default:
+		return errProtocol
+	}
+}
+
+// configureProtocol prepares srv to serve the protocols allowed by proto and returns the handler to serve.
+// HTTP/2 is served over TLS using ALPN and over cleartext (h2c) with prior knowledge or via Upgrade.
+// Requests to h using other protocol versions than the forced one are rejected with 505 status.
+//
+// Handlers already registered in mux (like the health endpoints, probed by HTTP/1.1 clients) serve
+// every protocol version, h is registered in mux as the handler of the other requests.
+func configureProtocol(srv *http.Server, proto string, mux *http.ServeMux, h http.Handler) (http.Handler, error) {
+	if err := validProtocol(proto); err != nil {
+		return nil, err
+	}
+
+	if mux == nil {
+		mux = http.NewServeMux()
+	}
+
+	if proto == protocolHTTP10 || proto == protocolHTTP11 {
+		// non-nil empty map disables HTTP/2 over TLS
+		srv.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+
+		if srv.TLSConfig != nil {
+			srv.TLSConfig.NextProtos = []string{yare.ProtocolHTTP11}
+		}
+
+		major, minor, _ := http.ParseHTTPVersion("HTTP/" + proto)
+
+		mux.Handle("/", requireProtocol(major, minor, h))
+
+		return mux, nil
+	}
+
+	h2s := new(http2.Server)
+
+	if err := http2.ConfigureServer(srv, h2s); err != nil {
+		return nil, err
+	}
+
+	if proto == protocolHTTP2 {
+		if srv.TLSConfig != nil {
+			srv.TLSConfig.NextProtos = []string{yare.ProtocolH2}
+		}
+
+		h = requireProtocol(2, 0, h)
+	}
+
+	mux.Handle("/", h)
+
+	return yare.H2CHandler(mux, h2s), nil
+}
+
+func requireProtocol(major, minor int, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != major || r.ProtoMinor != minor {
+			http.Error(w, http.StatusText(http.StatusHTTPVersionNotSupported), http.StatusHTTPVersionNotSupported)
+
+			return
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,156 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func protocolServer(t *testing.T, proto string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewUnstartedServer(nil)
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {})
+
+	h, err := configureProtocol(srv.Config, proto, mux, ok)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.Config.Handler = h
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func statusOf(t *testing.T, addr string, path string, proto string) int {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer conn.Close()
+
+	_, _ = conn.Write([]byte("GET " + path + " " + proto + "\r\nHost: localhost\r\nConnection: close\r\n\r\n"))
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	return resp.StatusCode
+}
+
+func Test_configureProtocol(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		proto  string
+		http10 int
+		http11 int
+	}{
+		{name: "any", proto: "", http10: http.StatusOK, http11: http.StatusOK},
+		{name: "1.0", proto: protocolHTTP10, http10: http.StatusOK, http11: http.StatusHTTPVersionNotSupported},
+		{name: "1.1", proto: protocolHTTP11, http10: http.StatusHTTPVersionNotSupported, http11: http.StatusOK},
+		{
+			name: "2", proto: protocolHTTP2,
+			http10: http.StatusHTTPVersionNotSupported, http11: http.StatusHTTPVersionNotSupported,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := protocolServer(t, tt.proto)
+			addr := srv.Listener.Addr().String()
+
+			if got := statusOf(t, addr, "/", "HTTP/1.0"); got != tt.http10 {
+				t.Errorf("HTTP/1.0 status = %d, want %d", got, tt.http10)
+			}
+
+			if got := statusOf(t, addr, "/", "HTTP/1.1"); got != tt.http11 {
+				t.Errorf("HTTP/1.1 status = %d, want %d", got, tt.http11)
+			}
+
+			for _, proto := range []string{"HTTP/1.0", "HTTP/1.1"} {
+				if got := statusOf(t, addr, "/healthz", proto); got != http.StatusOK {
+					t.Errorf("%s health status = %d, want %d", proto, got, http.StatusOK)
+				}
+			}
+		})
+	}
+}
+
+func Test_configureProtocolTLS(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		proto string
+		want  []string
+	}{
+		{proto: "", want: []string{"h2", "http/1.1"}},
+		{proto: protocolHTTP11, want: []string{"http/1.1"}},
+		{proto: protocolHTTP2, want: []string{"h2"}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run("protocol "+tt.proto, func(t *testing.T) {
+			t.Parallel()
+
+			srv := &http.Server{TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12}}
+
+			if _, err := configureProtocol(srv, tt.proto, nil, http.NotFoundHandler()); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := srv.TLSConfig.NextProtos; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NextProtos = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := configureProtocol(new(http.Server), "3", nil, http.NotFoundHandler()); err == nil {
+		t.Error("configureProtocol() error = nil, want error")
+	}
+}
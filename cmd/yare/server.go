@@ -0,0 +1,221 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/szkiba/yare"
+)
+
+var errHealthPrefix = errors.New("health prefix must start with / and must not end with /")
+
+const (
+	defaultReadHeaderTimeout = 10 * time.Second
+	defaultIdleTimeout       = 2 * time.Minute
+	defaultDrainTimeout      = 20 * time.Second
+	defaultHealthPrefix      = "/.yare"
+
+	trackerPollInterval = 100 * time.Millisecond
+)
+
+func validHealthPrefix(prefix string) error {
+	if len(prefix) != 0 && (!strings.HasPrefix(prefix, "/") || strings.HasSuffix(prefix, "/")) {
+		return errHealthPrefix
+	}
+
+	return nil
+}
+
+// newServer creates the http.Server with the configured timeouts.
+func newServer(o *options, cfg *tls.Config) *http.Server {
+	return &http.Server{
+		ConnContext:       yare.WireContext,
+		TLSConfig:         cfg,
+		ReadTimeout:       o.readTimeout,
+		ReadHeaderTimeout: o.readHeaderTimeout,
+		WriteTimeout:      o.writeTimeout,
+		IdleTimeout:       o.idleTimeout,
+	}
+}
+
+// health serves the liveness and readiness endpoints.
+// The server is live while the process runs and ready until the shutdown starts.
+type health struct {
+	draining int32
+	conns    connTracker
+}
+
+func (h *health) register(mux *http.ServeMux, prefix string) {
+	mux.HandleFunc(prefix+"/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeStatus(w, http.StatusOK, "ok")
+	})
+
+	mux.HandleFunc(prefix+"/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&h.draining) != 0 {
+			writeStatus(w, http.StatusServiceUnavailable, "shutting down")
+
+			return
+		}
+
+		writeStatus(w, http.StatusOK, "ok")
+	})
+}
+
+func writeStatus(w http.ResponseWriter, code int, status string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+
+	_, _ = w.Write([]byte(`{"status":"` + status + `"}` + "\n"))
+}
+
+// shutdown marks the server as not ready, waits delay to let load balancers notice it,
+// then waits at most timeout for active connections to finish.
+// Connections still active after timeout are closed.
+//
+// http.Server does not track hijacked connections (like h2c connections served by the HTTP/2 server),
+// they are tracked by the listeners returned by track.
+func (h *health) shutdown(srv *http.Server, delay, timeout time.Duration) error {
+	atomic.StoreInt32(&h.draining, 1)
+
+	time.Sleep(delay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := srv.Shutdown(ctx)
+	if err == nil {
+		err = h.conns.wait(ctx)
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = srv.Close()
+		h.conns.closeAll()
+	}
+
+	return err
+}
+
+// track returns a listener whose connections are waited for by shutdown, even if hijacked.
+func (h *health) track(l net.Listener) net.Listener {
+	return &trackedListener{Listener: l, tracker: &h.conns}
+}
+
+// connTracker is the set of open connections accepted by tracked listeners.
+type connTracker struct {
+	mu    sync.Mutex
+	conns map[*trackedConn]struct{}
+}
+
+func (t *connTracker) add(c *trackedConn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.conns == nil {
+		t.conns = make(map[*trackedConn]struct{})
+	}
+
+	t.conns[c] = struct{}{}
+}
+
+func (t *connTracker) remove(c *trackedConn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.conns, c)
+}
+
+func (t *connTracker) count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.conns)
+}
+
+// wait polls until every tracked connection is closed or ctx is done, like http.Server.Shutdown.
+func (t *connTracker) wait(ctx context.Context) error {
+	ticker := time.NewTicker(trackerPollInterval)
+	defer ticker.Stop()
+
+	for t.count() != 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	return nil
+}
+
+func (t *connTracker) closeAll() {
+	t.mu.Lock()
+	conns := make([]*trackedConn, 0, len(t.conns))
+
+	for c := range t.conns {
+		conns = append(conns, c)
+	}
+	t.mu.Unlock()
+
+	for _, c := range conns {
+		_ = c.Close()
+	}
+}
+
+type trackedListener struct {
+	net.Listener
+	tracker *connTracker
+}
+
+func (l *trackedListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &trackedConn{Conn: conn, tracker: l.tracker}
+	l.tracker.add(c)
+
+	return c, nil
+}
+
+type trackedConn struct {
+	net.Conn
+	tracker *connTracker
+	once    sync.Once
+}
+
+func (c *trackedConn) Close() error {
+	c.once.Do(func() { c.tracker.remove(c) })
+
+	return c.Conn.Close()
+}
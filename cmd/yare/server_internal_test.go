@@ -0,0 +1,300 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_validHealthPrefix(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		prefix  string
+		wantErr bool
+	}{
+		{prefix: ""},
+		{prefix: "/.yare"},
+		{prefix: "/a/b"},
+		{prefix: "/", wantErr: true},
+		{prefix: "/a/", wantErr: true},
+		{prefix: "yare", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.prefix, func(t *testing.T) {
+			t.Parallel()
+
+			if err := validHealthPrefix(tt.prefix); (err != nil) != tt.wantErr {
+				t.Errorf("validHealthPrefix() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_newServer(t *testing.T) {
+	t.Parallel()
+
+	o := defaultOptions()
+	o.readTimeout, o.writeTimeout = time.Second, time.Minute
+
+	srv := newServer(o, nil)
+
+	if srv.ReadTimeout != time.Second || srv.WriteTimeout != time.Minute {
+		t.Errorf("read, write timeout = %v, %v, want 1s, 1m", srv.ReadTimeout, srv.WriteTimeout)
+	}
+
+	if srv.ReadHeaderTimeout != 10*time.Second || srv.IdleTimeout != 2*time.Minute {
+		t.Errorf("read header, idle timeout = %v, %v, want 10s, 2m", srv.ReadHeaderTimeout, srv.IdleTimeout)
+	}
+}
+
+func get(t *testing.T, url string) (int, string) {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	return resp.StatusCode, string(body)
+}
+
+func Test_health(t *testing.T) {
+	t.Parallel()
+
+	h := new(health)
+	mux := http.NewServeMux()
+
+	h.register(mux, "/_")
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("echo"))
+	})
+
+	release := make(chan struct{})
+
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		_, _ = w.Write([]byte("slow"))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	for _, path := range []string{"/_/healthz", "/_/readyz"} {
+		if code, body := get(t, srv.URL+path); code != http.StatusOK || body != "{\"status\":\"ok\"}\n" {
+			t.Errorf("%s = %d %s, want %d", path, code, body, http.StatusOK)
+		}
+	}
+
+	if _, body := get(t, srv.URL+"/healthz"); body != "echo" {
+		t.Errorf("/healthz = %s, want echo", body)
+	}
+
+	slow := make(chan string)
+
+	go func() {
+		resp, err := http.Get(srv.URL + "/slow")
+		if err != nil {
+			slow <- err.Error()
+
+			return
+		}
+
+		defer resp.Body.Close()
+
+		body, _ := ioutil.ReadAll(resp.Body)
+		slow <- string(body)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+
+	done := make(chan error)
+
+	go func() {
+		done <- h.shutdown(srv.Config, 0, time.Minute)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/_/readyz", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("readyz while draining = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	close(release)
+
+	if body := <-slow; body != "slow" {
+		t.Errorf("active request = %s, want slow", body)
+	}
+
+	if err := <-done; err != nil {
+		t.Errorf("shutdown() error = %v", err)
+	}
+}
+
+func Test_healthShutdownTimeout(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	go func() {
+		resp, err := http.Get(srv.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+
+	start := time.Now()
+
+	if err := new(health).shutdown(srv.Config, 0, 100*time.Millisecond); err != nil {
+		t.Errorf("shutdown() error = %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("shutdown() took %v, want about 100ms", elapsed)
+	}
+}
+
+func Test_healthShutdownDelay(t *testing.T) {
+	t.Parallel()
+
+	h := new(health)
+	mux := http.NewServeMux()
+
+	h.register(mux, "")
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("echo"))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	done := make(chan error)
+
+	go func() {
+		done <- h.shutdown(srv.Config, 300*time.Millisecond, time.Minute)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	if code, _ := get(t, srv.URL+"/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("readyz during delay = %d, want %d", code, http.StatusServiceUnavailable)
+	}
+
+	if _, body := get(t, srv.URL+"/"); body != "echo" {
+		t.Errorf("request during delay = %s, want echo", body)
+	}
+
+	if err := <-done; err != nil {
+		t.Errorf("shutdown() error = %v", err)
+	}
+}
+
+func Test_healthShutdownHijacked(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		release bool
+	}{
+		{name: "closed by handler", timeout: time.Minute, release: true},
+		{name: "closed on timeout", timeout: 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := new(health)
+			release := make(chan struct{})
+
+			srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conn, _, err := w.(http.Hijacker).Hijack()
+				if err != nil {
+					return
+				}
+
+				<-release
+				conn.Close()
+			}))
+			srv.Listener = h.track(srv.Listener)
+			srv.Start()
+
+			defer srv.Close()
+
+			conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer conn.Close()
+
+			_, _ = conn.Write([]byte("GET / HTTP/1.1\r\nHost: x\r\n\r\n"))
+
+			time.Sleep(50 * time.Millisecond)
+
+			if tt.release {
+				time.AfterFunc(100*time.Millisecond, func() { close(release) })
+			} else {
+				defer close(release)
+			}
+
+			if err := h.shutdown(srv.Config, 0, tt.timeout); err != nil {
+				t.Errorf("shutdown() error = %v", err)
+			}
+
+			if n := h.conns.count(); n != 0 {
+				t.Errorf("open connections after shutdown = %d, want 0", n)
+			}
+
+			_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+
+			if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+				t.Errorf("hijacked connection read error = %v, want EOF", err)
+			}
+		})
+	}
+}
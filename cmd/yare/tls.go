@@ -0,0 +1,190 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"time"
+)
+
+var (
+	errTLSKeyPair    = errors.New("-tls-cert and -tls-key must be used together")
+	errTLSSelfSigned = errors.New("-tls-self-signed can not be used with -tls-cert")
+	errClientCA      = errors.New("-client-ca requires TLS")
+	errClientAuthTLS = errors.New("-client-auth requires TLS")
+	errClientAuth    = errors.New("-client-auth must be none, request, require, verify-if-given or require-and-verify")
+	errClientAuthCA  = errors.New("-client-auth verify-if-given and require-and-verify require -client-ca")
+	errNoCertificate = errors.New("no certificate found")
+)
+
+const selfSignedValidity = 365 * 24 * time.Hour
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify-if-given":    tls.VerifyClientCertIfGiven,
+	"require-and-verify": tls.RequireAndVerifyClientCert,
+}
+
+// newTLSConfig returns the TLS configuration of the server, nil if TLS is not enabled.
+func newTLSConfig(o *options) (*tls.Config, error) {
+	if (len(o.tlsCert) == 0) != (len(o.tlsKey) == 0) {
+		return nil, errTLSKeyPair
+	}
+
+	if o.tlsSelfSigned && len(o.tlsCert) != 0 {
+		return nil, errTLSSelfSigned
+	}
+
+	if len(o.tlsCert) == 0 && !o.tlsSelfSigned {
+		if len(o.clientCA) != 0 {
+			return nil, errClientCA
+		}
+
+		if len(o.clientAuth) != 0 {
+			return nil, errClientAuthTLS
+		}
+
+		return nil, nil
+	}
+
+	var (
+		cert tls.Certificate
+		err  error
+	)
+
+	if o.tlsSelfSigned {
+		cert, err = selfSignedCertificate()
+	} else {
+		cert, err = tls.LoadX509KeyPair(o.tlsCert, o.tlsKey)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// old protocol versions are allowed deliberately, to be able to echo any client
+	cfg := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS10}
+
+	if len(o.clientCA) != 0 {
+		if cfg.ClientCAs, err = loadCertPool(o.clientCA); err != nil {
+			return nil, err
+		}
+
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	if len(o.clientAuth) != 0 {
+		auth, ok := clientAuthTypes[o.clientAuth]
+		if !ok {
+			return nil, errClientAuth
+		}
+
+		// verifying against the system roots instead of a dedicated CA is rarely intended
+		if auth >= tls.VerifyClientCertIfGiven && cfg.ClientCAs == nil {
+			return nil, errClientAuthCA
+		}
+
+		cfg.ClientAuth = auth
+	}
+
+	return cfg, nil
+}
+
+func loadCertPool(filename string) (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%w: %s", errNoCertificate, filename)
+	}
+
+	return pool, nil
+}
+
+// selfSignedCertificate generates an in-memory self-signed certificate for localhost and the host name.
+func selfSignedCertificate() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	dnsNames := []string{"localhost"}
+	if host, err := os.Hostname(); err == nil && host != "localhost" {
+		dnsNames = append(dnsNames, host)
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "yare", Organization: []string{"yare self-signed"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              dnsNames,
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+// fingerprint returns the SHA-256 fingerprint of the leaf certificate.
+func fingerprint(cert tls.Certificate) string {
+	if len(cert.Certificate) == 0 {
+		return ""
+	}
+
+	sum := sha256.Sum256(cert.Certificate[0])
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,146 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func writePEM(t *testing.T, dir string, cert tls.Certificate) (string, string) {
+	t.Helper()
+
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+
+	_ = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0o600)
+	_ = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key}), 0o600)
+
+	return certFile, keyFile
+}
+
+func Test_newTLSConfig(t *testing.T) {
+	t.Parallel()
+
+	cert, err := selfSignedCertificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile := writePEM(t, dir, cert)
+
+	tests := []struct {
+		name    string
+		opts    options
+		nilCfg  bool
+		auth    tls.ClientAuthType
+		wantErr bool
+	}{
+		{name: "disabled", nilCfg: true},
+		{name: "files", opts: options{tlsCert: certFile, tlsKey: keyFile}},
+		{name: "self-signed", opts: options{tlsSelfSigned: true}},
+		{
+			name: "client-ca", opts: options{tlsSelfSigned: true, clientCA: certFile},
+			auth: tls.VerifyClientCertIfGiven,
+		},
+		{
+			name: "client-auth", opts: options{tlsSelfSigned: true, clientCA: certFile, clientAuth: "require-and-verify"},
+			auth: tls.RequireAndVerifyClientCert,
+		},
+		{name: "client-auth without ca", opts: options{tlsSelfSigned: true, clientAuth: "request"}, auth: tls.RequestClientCert},
+		{name: "missing key", opts: options{tlsCert: certFile}, wantErr: true},
+		{name: "self-signed with cert", opts: options{tlsCert: certFile, tlsKey: keyFile, tlsSelfSigned: true}, wantErr: true},
+		{name: "client-ca without tls", opts: options{clientCA: certFile}, wantErr: true},
+		{name: "client-auth without tls", opts: options{clientAuth: "request"}, wantErr: true},
+		{name: "verify without ca", opts: options{tlsSelfSigned: true, clientAuth: "verify-if-given"}, wantErr: true},
+		{
+			name: "require and verify without ca", opts: options{tlsSelfSigned: true, clientAuth: "require-and-verify"},
+			wantErr: true,
+		},
+		{name: "invalid client-auth", opts: options{tlsSelfSigned: true, clientAuth: "dummy"}, wantErr: true},
+		{name: "invalid client-ca", opts: options{tlsSelfSigned: true, clientCA: keyFile}, wantErr: true},
+		{name: "missing client-ca", opts: options{tlsSelfSigned: true, clientCA: filepath.Join(dir, "x")}, wantErr: true},
+		{name: "missing cert", opts: options{tlsCert: filepath.Join(dir, "x"), tlsKey: keyFile}, wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg, err := newTLSConfig(&tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newTLSConfig() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if (cfg == nil) != tt.nilCfg {
+				t.Errorf("newTLSConfig() = %v, want nil %v", cfg, tt.nilCfg)
+
+				return
+			}
+
+			if cfg != nil && cfg.ClientAuth != tt.auth {
+				t.Errorf("newTLSConfig() ClientAuth = %v, want %v", cfg.ClientAuth, tt.auth)
+			}
+		})
+	}
+}
+
+func Test_selfSignedCertificate(t *testing.T) {
+	t.Parallel()
+
+	cert, err := selfSignedCertificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := leaf.VerifyHostname("localhost"); err != nil {
+		t.Error(err)
+	}
+
+	if err := leaf.VerifyHostname("127.0.0.1"); err != nil {
+		t.Error(err)
+	}
+
+	if len(fingerprint(cert)) != 64 {
+		t.Errorf("fingerprint() = %v", fingerprint(cert))
+	}
+}
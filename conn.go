@@ -0,0 +1,151 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"time"
+)
+
+// mapConnection creates the connection section of a request.
+// Trailers are available only after reading the whole body.
+func mapConnection(r *http.Request) Dict {
+	out := make(Dict)
+
+	if len(r.RemoteAddr) != 0 {
+		out["remote"] = r.RemoteAddr
+	}
+
+	if addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+		out["local"] = addr.String()
+		out["network"] = addr.Network()
+	}
+
+	out["protocol"] = mapProtocol(r)
+
+	if len(r.Host) != 0 {
+		out["host"] = r.Host
+	}
+
+	if len(r.RequestURI) != 0 {
+		out["request_uri"] = r.RequestURI
+	}
+
+	if r.ContentLength >= 0 {
+		out["content_length"] = r.ContentLength
+	}
+
+	if len(r.TransferEncoding) != 0 {
+		out["transfer_encoding"] = r.TransferEncoding
+	}
+
+	if d := omitEmpty(MapValues(r.Trailer)); d != nil {
+		out["trailers"] = canonicalHeaderKeys(d)
+	}
+
+	return out
+}
+
+// mapTLS creates the tls section from the connection state.
+func mapTLS(cs *tls.ConnectionState) Dict {
+	out := Dict{
+		"version":      tls.VersionName(cs.Version),
+		"cipher_suite": tls.CipherSuiteName(cs.CipherSuite),
+		"resumed":      cs.DidResume,
+	}
+
+	if len(cs.NegotiatedProtocol) != 0 {
+		out["alpn"] = cs.NegotiatedProtocol
+	}
+
+	if len(cs.ServerName) != 0 {
+		out["server_name"] = cs.ServerName
+	}
+
+	if len(cs.PeerCertificates) != 0 {
+		certs := make([]interface{}, len(cs.PeerCertificates))
+		for i, cert := range cs.PeerCertificates {
+			certs[i] = mapCertificate(cert)
+		}
+
+		out["client_certificates"] = certs
+		out["verified"] = len(cs.VerifiedChains) != 0
+	}
+
+	return out
+}
+
+func mapCertificate(cert *x509.Certificate) Dict {
+	sum1 := sha1.Sum(cert.Raw)
+	sum256 := sha256.Sum256(cert.Raw)
+
+	out := Dict{
+		"subject":    cert.Subject.String(),
+		"issuer":     cert.Issuer.String(),
+		"serial":     cert.SerialNumber.String(),
+		"not_before": cert.NotBefore.UTC().Format(time.RFC3339),
+		"not_after":  cert.NotAfter.UTC().Format(time.RFC3339),
+		"sha1":       hex.EncodeToString(sum1[:]),
+		"sha256":     hex.EncodeToString(sum256[:]),
+	}
+
+	sans := make(Dict)
+
+	if len(cert.DNSNames) != 0 {
+		sans["dns"] = cert.DNSNames
+	}
+
+	if len(cert.EmailAddresses) != 0 {
+		sans["email"] = cert.EmailAddresses
+	}
+
+	if len(cert.IPAddresses) != 0 {
+		ips := make([]string, len(cert.IPAddresses))
+		for i, ip := range cert.IPAddresses {
+			ips[i] = ip.String()
+		}
+
+		sans["ip"] = ips
+	}
+
+	if len(cert.URIs) != 0 {
+		uris := make([]string, len(cert.URIs))
+		for i, u := range cert.URIs {
+			uris[i] = u.String()
+		}
+
+		sans["uri"] = uris
+	}
+
+	if len(sans) != 0 {
+		out["sans"] = sans
+	}
+
+	return out
+}
@@ -0,0 +1,190 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/szkiba/yare"
+)
+
+func clientCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"client.example.com"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func echo(t *testing.T, client *http.Client, req *http.Request) map[string]interface{} {
+	t.Helper()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	data, _ := ioutil.ReadAll(resp.Body)
+	got, _ := yare.ParseJSON(data)
+
+	return got
+}
+
+func TestMapRequestTLS(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewUnstartedServer(yare.NewMapper().EchoHandlerWithOptions(yare.WithConnection(true)))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert, MinVersion: tls.VersionTLS13}
+	srv.StartTLS()
+
+	defer srv.Close()
+
+	client := srv.Client()
+	transport, _ := client.Transport.(*http.Transport)
+	transport.TLSClientConfig.Certificates = []tls.Certificate{clientCertificate(t)}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+
+	got := echo(t, client, req)
+
+	state, _ := got["tls"].(map[string]interface{})
+
+	if state["version"] != "TLS 1.3" || state["resumed"] != false || state["verified"] != false {
+		t.Errorf("tls = %v", state)
+	}
+
+	if suite, _ := state["cipher_suite"].(string); !strings.HasPrefix(suite, "TLS_") {
+		t.Errorf("tls cipher_suite = %v", state["cipher_suite"])
+	}
+
+	certs, _ := state["client_certificates"].([]interface{})
+	if len(certs) != 1 {
+		t.Fatalf("tls client_certificates = %v", state["client_certificates"])
+	}
+
+	cert, _ := certs[0].(map[string]interface{})
+
+	if cert["subject"] != "CN=client" || cert["issuer"] != "CN=client" || cert["serial"] != "42" {
+		t.Errorf("client certificate = %v", cert)
+	}
+
+	want := map[string]interface{}{"dns": []interface{}{"client.example.com"}}
+	if !reflect.DeepEqual(cert["sans"], want) {
+		t.Errorf("client certificate sans = %v, want %v", cert["sans"], want)
+	}
+
+	if sum, _ := cert["sha256"].(string); len(sum) != 64 {
+		t.Errorf("client certificate sha256 = %v", cert["sha256"])
+	}
+
+	conn, _ := got["connection"].(map[string]interface{})
+	if conn["local"] != srv.Listener.Addr().String() {
+		t.Errorf("connection local = %v, want %v", conn["local"], srv.Listener.Addr())
+	}
+}
+
+func TestMapRequestConnection(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(yare.NewMapper().EchoHandlerWithOptions(yare.WithBody(true), yare.WithConnection(true)))
+	defer srv.Close()
+
+	body := &chunkedBody{data: "hi"}
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/path?foo=bar", body)
+	req.Trailer = http.Header{"X-Sum": nil}
+	body.trailer = req.Trailer
+
+	got := echo(t, srv.Client(), req)
+
+	want := map[string]interface{}{
+		"remote":            got["connection"].(map[string]interface{})["remote"],
+		"local":             srv.Listener.Addr().String(),
+		"network":           "tcp",
+		"host":              srv.Listener.Addr().String(),
+		"request_uri":       "/path?foo=bar",
+		"protocol":          map[string]interface{}{"name": yare.ProtocolHTTP11},
+		"transfer_encoding": []interface{}{"chunked"},
+		"trailers":          map[string]interface{}{"X-Sum": "42"},
+	}
+	if !reflect.DeepEqual(got["connection"], want) {
+		t.Errorf("connection = %v, want %v", got["connection"], want)
+	}
+
+	if _, ok := got["tls"]; ok {
+		t.Errorf("tls = %v, want none", got["tls"])
+	}
+}
+
+// chunkedBody is a request body of unknown length, which sets the trailer at the end.
+type chunkedBody struct {
+	data    string
+	trailer http.Header
+}
+
+func (b *chunkedBody) Read(p []byte) (int, error) {
+	if len(b.data) == 0 {
+		b.trailer.Set("X-Sum", "42")
+
+		return 0, io.EOF
+	}
+
+	n := copy(p, b.data)
+	b.data = b.data[n:]
+
+	return n, nil
+}
@@ -0,0 +1,217 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errCookieMalformed = errors.New("malformed cookie")
+	errCookieAttribute = errors.New("invalid cookie attribute")
+)
+
+// mapSetCookies creates Dict from Set-Cookie header values.
+//
+// Each cookie is mapped to a Dict of its value and attributes, the cookies are keyed by name.
+// Cookies occurring multiple times are stored as array and those with the same name, domain and
+// path are flagged as duplicate. Cookies with invalid syntax are flagged as malformed.
+func mapSetCookies(lines []string) (Dict, []error) {
+	out := make(Dict)
+	errs := []error{}
+	seen := make(map[string]Dict)
+
+	for _, line := range lines {
+		name, cookie, err := parseSetCookie(line)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		if cookie == nil {
+			continue
+		}
+
+		domain, _ := cookie["domain"].(string)
+		path, _ := cookie["path"].(string)
+		id := name + ";" + strings.ToLower(domain) + ";" + path
+
+		if prev, ok := seen[id]; ok {
+			prev["duplicate"] = true
+			cookie["duplicate"] = true
+		} else {
+			seen[id] = cookie
+		}
+
+		switch prev := out[name].(type) {
+		case nil:
+			out[name] = cookie
+		case Dict:
+			out[name] = []interface{}{prev, cookie}
+		case []interface{}:
+			out[name] = append(prev, cookie)
+		}
+	}
+
+	return out, errs
+}
+
+// parseSetCookie parses a single Set-Cookie header value.
+// The returned cookie is nil if even the name can not be parsed.
+func parseSetCookie(line string) (string, Dict, error) {
+	parts := strings.Split(line, ";")
+
+	pair := strings.TrimSpace(parts[0])
+
+	idx := strings.IndexByte(pair, '=')
+	if idx < 0 {
+		return "", nil, fmt.Errorf("%w: missing '=' in %q", errCookieMalformed, pair)
+	}
+
+	name := strings.TrimSpace(pair[:idx])
+	if !isCookieName(name) {
+		return "", nil, fmt.Errorf("%w: invalid name %q", errCookieMalformed, name)
+	}
+
+	errs := []error{}
+
+	value := strings.TrimSpace(pair[idx+1:])
+	if len(value) > 1 && value[0] == '"' && value[len(value)-1] == '"' {
+		value = value[1 : len(value)-1]
+	}
+
+	if !isCookieValue(value) {
+		errs = append(errs, fmt.Errorf("%w: invalid value of %s", errCookieMalformed, name))
+	}
+
+	cookie := Dict{"value": value}
+
+	for _, part := range parts[1:] {
+		if err := mapCookieAttribute(cookie, strings.TrimSpace(part)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	if len(errs) != 0 {
+		cookie["malformed"] = true
+
+		return name, cookie, wrapError(errs...)
+	}
+
+	return name, cookie, nil
+}
+
+func mapCookieAttribute(cookie Dict, attr string) error {
+	if len(attr) == 0 {
+		return nil
+	}
+
+	key, val := attr, ""
+
+	if idx := strings.IndexByte(attr, '='); idx >= 0 {
+		key, val = strings.TrimSpace(attr[:idx]), strings.TrimSpace(attr[idx+1:])
+	}
+
+	switch strings.ToLower(key) {
+	case "domain":
+		cookie["domain"] = strings.TrimPrefix(val, ".")
+	case "path":
+		cookie["path"] = val
+	case "expires":
+		t, err := parseCookieTime(val)
+		if err != nil {
+			cookie["expires"] = val
+
+			return fmt.Errorf("%w: expires %q", errCookieAttribute, val)
+		}
+
+		cookie["expires"] = t.UTC().Format(time.RFC3339)
+	case "max-age":
+		n, err := strconv.Atoi(val)
+		if err != nil {
+			return fmt.Errorf("%w: max-age %q", errCookieAttribute, val)
+		}
+
+		cookie["max_age"] = n
+	case "secure":
+		cookie["secure"] = true
+	case "httponly":
+		cookie["http_only"] = true
+	case "partitioned":
+		cookie["partitioned"] = true
+	case "samesite":
+		switch strings.ToLower(val) {
+		case "strict":
+			cookie["same_site"] = "Strict"
+		case "lax":
+			cookie["same_site"] = "Lax"
+		case "none":
+			cookie["same_site"] = "None"
+		default:
+			cookie["same_site"] = val
+
+			return fmt.Errorf("%w: samesite %q", errCookieAttribute, val)
+		}
+	default:
+		ext, _ := cookie["extensions"].([]string)
+		cookie["extensions"] = append(ext, attr)
+	}
+
+	return nil
+}
+
+func parseCookieTime(val string) (time.Time, error) {
+	if t, err := time.Parse("Mon, 02-Jan-2006 15:04:05 MST", val); err == nil {
+		return t, nil
+	}
+
+	return http.ParseTime(val)
+}
+
+func isCookieName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+
+	for _, r := range name {
+		if r <= ' ' || r >= 0x7f || strings.ContainsRune("()<>@,;:\\\"/[]?={}", r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isCookieValue(value string) bool {
+	for _, r := range value {
+		if r <= ' ' || r >= 0x7f || strings.ContainsRune("\",;\\", r) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,136 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/szkiba/yare"
+)
+
+func TestMapResponseSetCookie(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		lines   []string
+		want    yare.Dict
+		wantErr bool
+	}{
+		{
+			name: "attributes",
+			lines: []string{
+				"session=abc; Domain=.example.com; Path=/; Expires=Wed, 21 Oct 2015 07:28:00 GMT; " +
+					"Max-Age=3600; Secure; HttpOnly; SameSite=lax; Partitioned",
+			},
+			want: yare.Dict{"session": yare.Dict{
+				"value": "abc", "domain": "example.com", "path": "/", "expires": "2015-10-21T07:28:00Z",
+				"max_age": 3600, "secure": true, "http_only": true, "same_site": "Lax", "partitioned": true,
+			}},
+		},
+		{
+			name:  "quoted value and extension",
+			lines: []string{`id="foo"; Priority=High`},
+			want:  yare.Dict{"id": yare.Dict{"value": "foo", "extensions": []string{"Priority=High"}}},
+		},
+		{
+			name:  "same name different path",
+			lines: []string{"id=1; Path=/a", "id=2; Path=/b"},
+			want: yare.Dict{"id": []interface{}{
+				yare.Dict{"value": "1", "path": "/a"},
+				yare.Dict{"value": "2", "path": "/b"},
+			}},
+		},
+		{
+			name:  "duplicate",
+			lines: []string{"id=1; Path=/", "id=2; Path=/"},
+			want: yare.Dict{"id": []interface{}{
+				yare.Dict{"value": "1", "path": "/", "duplicate": true},
+				yare.Dict{"value": "2", "path": "/", "duplicate": true},
+			}},
+		},
+		{
+			name:    "malformed attribute",
+			lines:   []string{"id=1; Max-Age=soon; SameSite=Sometimes"},
+			want:    yare.Dict{"id": yare.Dict{"value": "1", "same_site": "Sometimes", "malformed": true}},
+			wantErr: true,
+		},
+		{
+			name:    "malformed",
+			lines:   []string{"garbage", "id=1"},
+			want:    yare.Dict{"id": yare.Dict{"value": "1"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			resp := newResponse(par{})
+			defer resp.Body.Close()
+
+			for _, line := range tt.lines {
+				resp.Header.Add("Set-Cookie", line)
+			}
+
+			got, err := yare.MapResponseWithOptions(resp, yare.WithHeaders(false))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MapResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var merr *yare.MapError
+			if err != nil && (!errors.As(err, &merr) || merr.Errors[0].Section != "cookies") {
+				t.Errorf("MapResponse() error = %v, want cookies MapError", err)
+			}
+
+			if !reflect.DeepEqual(got["cookies"], tt.want) {
+				t.Errorf("MapResponse() cookies = %v, want %v", got["cookies"], tt.want)
+			}
+		})
+	}
+}
+
+func TestMapResponseSetCookieRedact(t *testing.T) {
+	t.Parallel()
+
+	resp := newResponse(par{})
+	defer resp.Body.Close()
+
+	resp.Header.Add("Set-Cookie", "session=abc; Secure")
+
+	r := yare.NewRedactor()
+	_ = r.AddKey(yare.RedactCookies, "*")
+
+	got, _ := yare.MapResponseWithOptions(resp, yare.WithRedactor(r))
+
+	want := yare.Dict{"session": yare.Dict{"value": yare.DefaultRedactMask, "secure": true}}
+	if !reflect.DeepEqual(got["cookies"], want) {
+		t.Errorf("MapResponse() cookies = %v, want %v", got["cookies"], want)
+	}
+
+	if v := resp.Header.Get("Set-Cookie"); v != "session=abc; Secure" {
+		t.Errorf("response header modified: %v", v)
+	}
+}
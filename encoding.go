@@ -0,0 +1,123 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// DefaultMaxDecodedSize is the default limit of decoded (uncompressed) body size.
+const DefaultMaxDecodedSize = 32 * 1024 * 1024
+
+var (
+	errUnsupportedEncoding = errors.New("unsupported content encoding")
+	errDecodedTooLarge     = errors.New("decoded content too large")
+)
+
+// contentCodings returns the content codings listed in Content-Encoding header values in applied order.
+func contentCodings(values []string) []string {
+	codings := []string{}
+
+	for _, value := range values {
+		for _, coding := range strings.Split(value, ",") {
+			coding = strings.ToLower(strings.TrimSpace(coding))
+			if len(coding) != 0 && coding != "identity" {
+				codings = append(codings, coding)
+			}
+		}
+	}
+
+	return codings
+}
+
+// decodeContent removes content codings in reverse order of application.
+func decodeContent(codings []string, body []byte, max int64) ([]byte, error) {
+	data := body
+
+	for i := len(codings) - 1; i >= 0; i-- {
+		r, err := newContentDecoder(codings[i], data, max)
+		if err != nil {
+			return nil, wrapError(err)
+		}
+
+		data, err = ioutil.ReadAll(io.LimitReader(r, max+1))
+		r.Close()
+
+		if err != nil {
+			return nil, wrapError(fmt.Errorf("%s: %w", codings[i], err))
+		}
+
+		if int64(len(data)) > max {
+			return nil, wrapError(fmt.Errorf("%w: more than %d bytes", errDecodedTooLarge, max))
+		}
+	}
+
+	return data, nil
+}
+
+func newContentDecoder(coding string, data []byte, max int64) (io.ReadCloser, error) {
+	r := bytes.NewReader(data)
+
+	switch coding {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(r)
+	case "deflate":
+		if isZlib(data) {
+			return zlib.NewReader(r)
+		}
+
+		// some implementations send raw deflate stream without zlib wrapper
+		return flate.NewReader(r), nil
+	case "br":
+		return ioutil.NopCloser(brotli.NewReader(r)), nil
+	case "zstd":
+		d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(uint64(max)))
+		if err != nil {
+			return nil, err
+		}
+
+		return d.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedEncoding, coding)
+	}
+}
+
+func isZlib(data []byte) bool {
+	const (
+		deflateMethod = 8
+		checkDivisor  = 31
+	)
+
+	return len(data) >= 2 && data[0]&0x0f == deflateMethod && (uint16(data[0])<<8|uint16(data[1]))%checkDivisor == 0
+}
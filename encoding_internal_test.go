@@ -0,0 +1,142 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+func encode(t *testing.T, coding string, data []byte) []byte {
+	t.Helper()
+
+	var (
+		buff bytes.Buffer
+		w    io.WriteCloser
+		err  error
+	)
+
+	switch coding {
+	case "gzip":
+		w = gzip.NewWriter(&buff)
+	case "deflate":
+		w = zlib.NewWriter(&buff)
+	case "raw-deflate":
+		w, err = flate.NewWriter(&buff, flate.DefaultCompression)
+	case "br":
+		w = brotli.NewWriter(&buff)
+	case "zstd":
+		w, err = zstd.NewWriter(&buff)
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _ = w.Write(data)
+	_ = w.Close()
+
+	return buff.Bytes()
+}
+
+func Test_contentCodings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		values []string
+		want   []string
+	}{
+		{name: "empty", values: nil, want: []string{}},
+		{name: "identity", values: []string{"identity"}, want: []string{}},
+		{name: "single", values: []string{"GZIP"}, want: []string{"gzip"}},
+		{name: "list", values: []string{"gzip, br"}, want: []string{"gzip", "br"}},
+		{name: "multiple", values: []string{"deflate", " zstd "}, want: []string{"deflate", "zstd"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := contentCodings(tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("contentCodings() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_decodeContent(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(strings.Repeat("Hello, World! ", 100))
+
+	tests := []struct {
+		name    string
+		codings []string
+		body    []byte
+		max     int64
+		wantErr bool
+	}{
+		{name: "gzip", codings: []string{"gzip"}, body: encode(t, "gzip", data)},
+		{name: "x-gzip", codings: []string{"x-gzip"}, body: encode(t, "gzip", data)},
+		{name: "deflate", codings: []string{"deflate"}, body: encode(t, "deflate", data)},
+		{name: "raw deflate", codings: []string{"deflate"}, body: encode(t, "raw-deflate", data)},
+		{name: "br", codings: []string{"br"}, body: encode(t, "br", data)},
+		{name: "zstd", codings: []string{"zstd"}, body: encode(t, "zstd", data)},
+		{name: "chain", codings: []string{"gzip", "br"}, body: encode(t, "br", encode(t, "gzip", data))},
+		{name: "none", codings: []string{}, body: data},
+		{name: "unsupported", codings: []string{"compress"}, body: data, wantErr: true},
+		{name: "malformed", codings: []string{"gzip"}, body: data, wantErr: true},
+		{name: "too large", codings: []string{"gzip"}, body: encode(t, "gzip", data), max: 100, wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			max := tt.max
+			if max == 0 {
+				max = DefaultMaxDecodedSize
+			}
+
+			got, err := decodeContent(tt.codings, tt.body, max)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("decodeContent() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+
+			if !tt.wantErr && !bytes.Equal(got, data) {
+				t.Errorf("decodeContent() = %q, want %q", got, data)
+			}
+		})
+	}
+}
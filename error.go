@@ -23,6 +23,7 @@
 package yare
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -33,6 +34,21 @@ var ErrParse = errors.New("parse error")
 
 var errParsePrefixLen = len(ErrParse.Error()) + 1
 
+// parseError is the error created by wrapError.
+// It keeps the wrapped errors, so they remain reachable by errors.Is and errors.As.
+type parseError struct {
+	msg  string
+	errs []error
+}
+
+func (e *parseError) Error() string {
+	return e.msg
+}
+
+func (e *parseError) Unwrap() []error {
+	return append([]error{ErrParse}, e.errs...)
+}
+
 func wrapError(err ...error) error {
 	var sb strings.Builder
 
@@ -44,16 +60,157 @@ func wrapError(err ...error) error {
 			sb.WriteRune(',')
 		}
 
-		str := e.Error()
+		sb.WriteString(errorMessage(e))
+	}
 
-		if errors.Is(e, ErrParse) {
-			str = str[errParsePrefixLen:]
-		} else if strings.Contains(str, ",") {
-			str = fmt.Sprintf("%q", str)
-		}
+	return &parseError{msg: ErrParse.Error() + "," + sb.String(), errs: err}
+}
+
+// errorMessage returns the error message without ErrParse prefix, quoted if it contains comma.
+func errorMessage(err error) string {
+	str := err.Error()
+
+	if errors.Is(err, ErrParse) && strings.HasPrefix(str, ErrParse.Error()+",") {
+		return str[errParsePrefixLen:]
+	}
+
+	if strings.Contains(str, ",") {
+		return fmt.Sprintf("%q", str)
+	}
+
+	return str
+}
+
+// plainMessage returns the error message without ErrParse prefix and quoting.
+func plainMessage(err error) string {
+	if perr, ok := err.(*parseError); ok && len(perr.errs) == 1 {
+		return plainMessage(perr.errs[0])
+	}
+
+	return strings.TrimPrefix(err.Error(), ErrParse.Error()+",")
+}
+
+// FieldError describes the failure of mapping a single section of a request or a response.
+type FieldError struct {
+	// Section is the name of the failed section, like form, body, multipart, encoding, charset or authorization.
+	Section string
+	// ContentType is the Content-Type of the failed content, if any.
+	ContentType string
+	// Offset is the byte offset of the failure in the (decoded) content, -1 if unknown.
+	Offset int64
+	// Err is the underlying error.
+	Err error
+}
+
+func newFieldError(section, cty string, err error) *FieldError {
+	offset := int64(-1)
+
+	var syntaxErr *json.SyntaxError
+
+	var typeErr *json.UnmarshalTypeError
+
+	if errors.As(err, &syntaxErr) {
+		offset = syntaxErr.Offset
+	} else if errors.As(err, &typeErr) {
+		offset = typeErr.Offset
+	}
+
+	return &FieldError{Section: section, ContentType: cty, Offset: offset, Err: err}
+}
+
+func (e *FieldError) Error() string {
+	return e.Section + ": " + plainMessage(e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+func (e *FieldError) dict() Dict {
+	out := Dict{"section": e.Section, "message": plainMessage(e.Err)}
+
+	if len(e.ContentType) != 0 {
+		out["content_type"] = e.ContentType
+	}
+
+	if e.Offset >= 0 {
+		out["offset"] = e.Offset
+	}
+
+	return out
+}
+
+// MapError is returned by request and response mapping, it collects the errors of the failed sections.
+//
+// For compatibility, MapError matches ErrParse with errors.Is.
+// The underlying errors are reachable with errors.Is and errors.As too.
+type MapError struct {
+	Errors []*FieldError
+}
+
+func (e *MapError) Error() string {
+	errs := make([]error, len(e.Errors))
+	for i, fe := range e.Errors {
+		errs[i] = fe
+	}
+
+	return wrapError(errs...).Error()
+}
+
+// Is reports whether target is ErrParse.
+func (e *MapError) Is(target error) bool {
+	return target == ErrParse
+}
+
+func (e *MapError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, fe := range e.Errors {
+		errs[i] = fe
+	}
+
+	return errs
+}
+
+// add appends err as FieldError, errors of a MapError and FieldError values are added as is.
+func (e *MapError) add(section, cty string, err error) {
+	var merr *MapError
+
+	var ferr *FieldError
+
+	switch {
+	case errors.As(err, &merr):
+		e.Errors = append(e.Errors, merr.Errors...)
+	case errors.As(err, &ferr):
+		e.Errors = append(e.Errors, ferr)
+	default:
+		e.Errors = append(e.Errors, newFieldError(section, cty, err))
+	}
+}
+
+// errorOrNil returns nil if there is no collected error.
+func (e *MapError) errorOrNil() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+func (e *MapError) dicts() []interface{} {
+	out := make([]interface{}, len(e.Errors))
+	for i, fe := range e.Errors {
+		out[i] = fe.dict()
+	}
+
+	return out
+}
 
-		sb.WriteString(str)
+// errorDicts returns the errors array of the mapped output for any error.
+func errorDicts(err error) []interface{} {
+	var merr *MapError
+	if errors.As(err, &merr) {
+		return merr.dicts()
 	}
 
-	return fmt.Errorf("%w,%s", ErrParse, sb.String())
+	return []interface{}{Dict{"message": err.Error()}}
 }
@@ -24,6 +24,7 @@ package yare
 
 import (
 	"errors"
+	"reflect"
 	"testing"
 )
 
@@ -89,3 +90,35 @@ func Test_wrapError(t *testing.T) {
 		})
 	}
 }
+
+func TestMapError(t *testing.T) {
+	t.Parallel()
+
+	errs := new(MapError)
+	if errs.errorOrNil() != nil {
+		t.Error("MapError.errorOrNil() is not nil")
+	}
+
+	errs.add("form", "", errDummy)
+	errs.add("body", "application/json", wrapError(errWithComma))
+	errs.add("body", "", &MapError{Errors: []*FieldError{newFieldError("multipart", "text/plain", errFoo)}})
+
+	err := errs.errorOrNil()
+
+	if want := `parse error,form: dummy,"body: foo,bar",multipart: foo`; err.Error() != want {
+		t.Errorf("MapError.Error() = %v, want %v", err.Error(), want)
+	}
+
+	if !errors.Is(err, ErrParse) || !errors.Is(err, errDummy) || !errors.Is(err, errWithComma) {
+		t.Errorf("MapError does not match wrapped errors")
+	}
+
+	want := []interface{}{
+		Dict{"section": "form", "message": "dummy"},
+		Dict{"section": "body", "content_type": "application/json", "message": "foo,bar"},
+		Dict{"section": "multipart", "content_type": "text/plain", "message": "foo"},
+	}
+	if got := errs.dicts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("MapError.dicts() = %v, want %v", got, want)
+	}
+}
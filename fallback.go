@@ -0,0 +1,91 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultRawBodyMax is the default maximum number of body bytes included by RawBody.
+const DefaultRawBodyMax = 64 * 1024
+
+// RawBody returns a ParserFunc which represents raw body content as Dict.
+//
+// Valid UTF-8 text is included as string (text), binary content as base64 encoded string (base64).
+// Both representations include the content size (size), the sniffed MIME type (type) and the SHA-256 digest (sha256)
+// of the whole content. At most max bytes of the content are included, in this case the truncated flag is set.
+// Non positive max means DefaultRawBodyMax.
+//
+// Can use as Mapper.Fallback.
+func RawBody(max int) ParserFunc {
+	if max <= 0 {
+		max = DefaultRawBodyMax
+	}
+
+	return func(in []byte) (Dict, error) {
+		detected := http.DetectContentType(in)
+		out := Dict{"size": len(in), "type": detected, "sha256": sha256Hex(in)}
+
+		content := in
+		if len(content) > max {
+			content = content[:max]
+			out["truncated"] = true
+		}
+
+		if isText(detected, in) {
+			out["text"] = string(trimRune(content))
+		} else {
+			out["base64"] = base64.StdEncoding.EncodeToString(content)
+		}
+
+		return out, nil
+	}
+}
+
+func isText(detected string, content []byte) bool {
+	return strings.HasPrefix(detected, "text/") && utf8.Valid(content)
+}
+
+// trimRune removes incomplete UTF-8 sequence from the end of a truncated text.
+func trimRune(content []byte) []byte {
+	for i := 0; i < utf8.UTFMax && len(content) > 0; i++ {
+		if r, size := utf8.DecodeLastRune(content); r != utf8.RuneError || size != 1 {
+			break
+		}
+
+		content = content[:len(content)-1]
+	}
+
+	return content
+}
+
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+
+	return hex.EncodeToString(sum[:])
+}
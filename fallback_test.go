@@ -0,0 +1,152 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare_test
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/szkiba/yare"
+)
+
+func TestRawBody(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		max int
+		in  string
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want yare.Dict
+	}{
+		{
+			name: "text",
+			args: args{in: "Hello"},
+			want: yare.Dict{
+				"size": 5, "type": "text/plain; charset=utf-8", "text": "Hello",
+				"sha256": "185f8db32271fe25f561a6fc938b2e264306ec304eda518007d1764826381969",
+			},
+		},
+		{
+			name: "binary",
+			args: args{in: "\x00\x01\x02"},
+			want: yare.Dict{
+				"size": 3, "type": "application/octet-stream", "base64": "AAEC",
+				"sha256": "ae4b3280e56e2faf83f414a6e3dabe9d5fbe18976544c05fed121accb85b53fc",
+			},
+		},
+		{
+			name: "truncated text",
+			args: args{max: 2, in: "Hé"},
+			want: yare.Dict{
+				"size": 3, "type": "text/plain; charset=utf-8", "text": "H", "truncated": true,
+				"sha256": "4b99951f5700a3f0a026d1026b5ec5f1f70cb67435e401d7e4340eaa7cf539e4",
+			},
+		},
+		{
+			name: "truncated binary",
+			args: args{max: 1, in: "\x00\x01\x02"},
+			want: yare.Dict{
+				"size": 3, "type": "application/octet-stream", "base64": "AA==", "truncated": true,
+				"sha256": "ae4b3280e56e2faf83f414a6e3dabe9d5fbe18976544c05fed121accb85b53fc",
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := yare.RawBody(tt.args.max)([]byte(tt.args.in))
+			if err != nil {
+				t.Errorf("RawBody() error = %v", err)
+
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RawBody() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMapper_Fallback(t *testing.T) {
+	t.Parallel()
+
+	cty := "test/" + t.Name()
+
+	m := yare.NewMapper()
+	m.Fallback = yare.RawBody(0)
+	_ = m.RegisterContentType(cty, yare.ParseJSON)
+
+	tests := []struct {
+		name    string
+		cty     string
+		body    string
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			name: "parsed", cty: cty, body: `{"foo":"bar"}`,
+			want: yare.Dict{"foo": "bar"},
+		},
+		{
+			name: "unknown", cty: "text/plain", body: "Hi",
+			want: yare.Dict{"size": 2, "type": "text/plain; charset=utf-8", "text": "Hi"},
+		},
+		{
+			name: "missing", cty: "", body: "Hi",
+			want: yare.Dict{"size": 2, "type": "text/plain; charset=utf-8", "text": "Hi"},
+		},
+		{
+			name: "malformed", cty: cty, body: `{"foo"`, wantErr: true,
+			want: yare.Dict{"size": 6, "type": "text/plain; charset=utf-8", "text": `{"foo"`},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := newRequest(par{method: http.MethodPost, body: tt.body, header: kv{"Content-Type": tt.cty}})
+
+			got, err := m.MapRequest(r, true)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Mapper.MapRequest() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+
+			body, _ := got["body"].(yare.Dict)
+			delete(body, "sha256")
+
+			if !reflect.DeepEqual(body, tt.want) {
+				t.Errorf("Mapper.MapRequest() body = %v, want %v", body, tt.want)
+			}
+		})
+	}
+}
@@ -1,5 +1,21 @@
 module github.com/szkiba/yare
 
-go 1.14
+go 1.21
 
-require github.com/dgrijalva/jwt-go v3.2.0+incompatible
+require (
+	github.com/BurntSushi/toml v1.3.2
+	github.com/andybalholm/brotli v1.0.6
+	github.com/fxamacker/cbor/v2 v2.5.0
+	github.com/golang-jwt/jwt/v4 v4.5.2
+	github.com/klauspost/compress v1.17.11
+	github.com/vmihailenco/msgpack/v5 v5.3.5
+	go.mongodb.org/mongo-driver v1.17.6
+	golang.org/x/net v0.28.0
+	golang.org/x/text v0.17.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+)
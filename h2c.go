@@ -0,0 +1,122 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare
+
+import (
+	"context"
+	"net/http"
+
+	"golang.org/x/net/http/httpguts"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// Protocol identifiers reported in the protocol part of the connection section.
+const (
+	ProtocolHTTP10 = "http/1.0"
+	ProtocolHTTP11 = "http/1.1"
+	ProtocolH2     = "h2"
+	ProtocolH2C    = "h2c"
+)
+
+// H2CHandler returns a handler which serves HTTP/2 over cleartext connections (h2c),
+// both with prior knowledge and via HTTP/1.1 Upgrade, and passes other requests to h.
+//
+// Upgraded requests are handed to h as HTTP/2.0 requests, so the mapped version reflects
+// the negotiated protocol. If s is nil, a zero http2.Server is used.
+func H2CHandler(h http.Handler, s *http2.Server) http.Handler {
+	if s == nil {
+		s = new(http2.Server)
+	}
+
+	upgraded := h2c.NewHandler(&h2cUpgradedHandler{handler: h}, s)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isH2CUpgrade(r.Header) {
+			r = r.WithContext(context.WithValue(r.Context(), h2cUpgradeContextKey{}, true))
+		}
+
+		upgraded.ServeHTTP(w, r)
+	})
+}
+
+type h2cUpgradeContextKey struct{}
+
+type h2cUpgradedHandler struct {
+	handler http.Handler
+}
+
+func (h *h2cUpgradedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// the h2c package serves the upgrade request itself on stream 1 of the new HTTP/2 connection,
+	// still as HTTP/1.1 request, while later streams arrive as HTTP/2.0 requests
+	if r.ProtoMajor == 1 && isH2CUpgraded(r) {
+		up := r.Clone(r.Context())
+		up.Proto, up.ProtoMajor, up.ProtoMinor = "HTTP/2.0", 2, 0
+
+		r = up
+	}
+
+	h.handler.ServeHTTP(w, r)
+}
+
+// isH2CUpgrade reports whether the header requests upgrade to h2c (RFC 7540 Section 3.2).
+func isH2CUpgrade(h http.Header) bool {
+	return httpguts.HeaderValuesContainsToken(h.Values("Upgrade"), "h2c") &&
+		httpguts.HeaderValuesContainsToken(h.Values("Connection"), "HTTP2-Settings")
+}
+
+func isH2CUpgraded(r *http.Request) bool {
+	up, _ := r.Context().Value(h2cUpgradeContextKey{}).(bool)
+
+	return up
+}
+
+// mapProtocol creates the protocol part of the connection section.
+func mapProtocol(r *http.Request) Dict {
+	out := make(Dict)
+
+	switch {
+	case r.ProtoMajor == 2 && r.TLS != nil:
+		out["name"] = ProtocolH2
+		out["negotiation"] = "alpn"
+	case r.ProtoMajor == 2 && isH2CUpgraded(r):
+		out["name"] = ProtocolH2C
+		out["negotiation"] = "upgrade"
+		out["stream"] = 1 // RFC 7540 Section 3.2: the upgrade request is stream 1
+	case r.ProtoMajor == 2:
+		out["name"] = ProtocolH2C
+		out["negotiation"] = "prior-knowledge"
+	case r.ProtoMajor == 1 && r.ProtoMinor == 0:
+		out["name"] = ProtocolHTTP10
+	case r.ProtoMajor == 1:
+		out["name"] = ProtocolHTTP11
+	default:
+		out["name"] = r.Proto
+	}
+
+	if r.ProtoMajor == 1 && r.TLS != nil && r.TLS.NegotiatedProtocol == ProtocolHTTP11 {
+		out["negotiation"] = "alpn"
+	}
+
+	return out
+}
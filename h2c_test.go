@@ -0,0 +1,162 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare_test
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/szkiba/yare"
+	"golang.org/x/net/http2"
+)
+
+func h2cServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(yare.H2CHandler(yare.NewMapper().EchoHandlerWithOptions(yare.WithConnection(true)), nil))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestH2CHandlerPriorKnowledge(t *testing.T) {
+	t.Parallel()
+
+	srv := h2cServer(t)
+
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return new(net.Dialer).DialContext(ctx, network, addr)
+		},
+	}}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+
+	got := echo(t, client, req)
+
+	if got["version"] != "HTTP/2.0" {
+		t.Errorf("version = %v, want HTTP/2.0", got["version"])
+	}
+
+	want := map[string]interface{}{"name": yare.ProtocolH2C, "negotiation": "prior-knowledge"}
+	if proto := got["connection"].(map[string]interface{})["protocol"]; !reflect.DeepEqual(proto, want) {
+		t.Errorf("protocol = %v, want %v", proto, want)
+	}
+}
+
+func TestH2CHandlerUpgrade(t *testing.T) {
+	t.Parallel()
+
+	srv := h2cServer(t)
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer conn.Close()
+
+	_, _ = conn.Write([]byte("GET /up HTTP/1.1\r\nHost: localhost\r\n" +
+		"Connection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: AAMAAABkAAQAAP__\r\n\r\n"))
+
+	rd := bufio.NewReader(conn)
+
+	resp, err := http.ReadResponse(rd, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusSwitchingProtocols)
+	}
+
+	_, _ = conn.Write([]byte(http2.ClientPreface))
+
+	framer := http2.NewFramer(conn, rd)
+	if err := framer.WriteSettings(); err != nil {
+		t.Fatal(err)
+	}
+
+	var body strings.Builder
+
+	for {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, ok := frame.(*http2.DataFrame)
+		if !ok || data.StreamID != 1 {
+			continue
+		}
+
+		body.Write(data.Data())
+
+		if data.StreamEnded() {
+			break
+		}
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(body.String()), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	// the upgrade request is passed to the handler as HTTP/1.1 request, H2CHandler rewrites its version
+	if got["version"] != "HTTP/2.0" {
+		t.Errorf("version = %v, want HTTP/2.0", got["version"])
+	}
+
+	want := map[string]interface{}{"name": yare.ProtocolH2C, "negotiation": "upgrade", "stream": float64(1)}
+	if proto := got["connection"].(map[string]interface{})["protocol"]; !reflect.DeepEqual(proto, want) {
+		t.Errorf("protocol = %v, want %v", proto, want)
+	}
+}
+
+func TestH2CHandlerHTTP1(t *testing.T) {
+	t.Parallel()
+
+	srv := h2cServer(t)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+
+	got := echo(t, srv.Client(), req)
+
+	if got["version"] != "HTTP/1.1" {
+		t.Errorf("version = %v, want HTTP/1.1", got["version"])
+	}
+
+	want := map[string]interface{}{"name": yare.ProtocolHTTP11}
+	if proto := got["connection"].(map[string]interface{})["protocol"]; !reflect.DeepEqual(proto, want) {
+		t.Errorf("protocol = %v, want %v", proto, want)
+	}
+}
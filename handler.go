@@ -23,29 +23,82 @@
 package yare
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
+)
+
+// Limits of reading the rest of a truncated body to report its real size.
+// The connection is closed after the response if the limits are exceeded.
+const (
+	drainLimit   = 32 * 1024 * 1024
+	drainTimeout = 10 * time.Second
 )
 
 // handle store internal handler configuration.
 type handler struct {
-	body bool
+	mapper *Mapper
+	opts   []Option
+}
+
+// EchoHandler returns a handler that serves HTTP requests with Echo response using DefaultMapper.
+func EchoHandler(body bool) http.Handler {
+	return DefaultMapper.EchoHandler(body)
 }
 
-// EchoHander returns a handler that serves HTTP requests with Echo response.
+// EchoHander returns a handler that serves HTTP requests with Echo response using DefaultMapper.
+//
+// Deprecated: use EchoHandler instead.
 func EchoHander(body bool) http.Handler {
-	return &handler{body: body}
+	return EchoHandler(body)
+}
+
+// EchoHandlerWithOptions returns a handler that serves HTTP requests with Echo response
+// using DefaultMapper and the given options.
+func EchoHandlerWithOptions(opts ...Option) http.Handler {
+	return DefaultMapper.EchoHandlerWithOptions(opts...)
+}
+
+// EchoHandler returns a handler that serves HTTP requests with Echo response.
+func (m *Mapper) EchoHandler(body bool) http.Handler {
+	return m.EchoHandlerWithOptions(WithBody(body))
+}
+
+// EchoHandlerWithOptions returns a handler that serves HTTP requests with Echo response using the given options.
+func (m *Mapper) EchoHandlerWithOptions(opts ...Option) http.Handler {
+	return &handler{mapper: m, opts: opts}
 }
 
 // ServeHTTP is a http handler method.
 func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	dict, err := MapRequest(r, h.body)
+	dict, err := h.mapRequest(r)
 	status := http.StatusOK
 
+	// report the real size of truncated body
+	if c, ok := r.Body.(*bodyCapture); ok {
+		// not every ResponseWriter supports deadlines, then only the size limit applies
+		_ = http.NewResponseController(w).SetReadDeadline(time.Now().Add(drainTimeout))
+
+		if size, complete, derr := c.drain(drainLimit); derr == nil && complete {
+			if capture, ok := dict["capture"].(Dict); ok {
+				capture["size"] = size
+			}
+		} else {
+			w.Header().Set("Connection", "close")
+		}
+	}
+
 	if err != nil {
 		addError(w, err)
 
 		status = http.StatusBadRequest
+
+		var merr *MapError
+		if dict != nil && errors.As(err, &merr) {
+			dict["errors"] = merr.dicts()
+		}
 	}
 
 	if dict == nil {
@@ -64,6 +117,48 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// mappedRequest is a request mapping passed by a middleware to the echo handler in the request context.
+type mappedRequest struct {
+	mapper *Mapper
+	opts   []Option
+	dict   Dict
+	err    error
+}
+
+type mappedContextKey struct{}
+
+// shareMapping returns the request with the mapping stored in its context.
+func shareMapping(r *http.Request, m *Mapper, opts []Option, dict Dict, err error) *http.Request {
+	mapped := &mappedRequest{mapper: m, opts: opts, dict: dict, err: err}
+
+	return r.WithContext(context.WithValue(r.Context(), mappedContextKey{}, mapped))
+}
+
+// mapRequest maps the request, reusing the mapping of a middleware if it was made
+// by the same Mapper with the same option slice.
+func (h *handler) mapRequest(r *http.Request) (Dict, error) {
+	mapped, ok := r.Context().Value(mappedContextKey{}).(*mappedRequest)
+	if !ok || mapped.mapper != h.mapper || !sameOptions(mapped.opts, h.opts) {
+		return h.mapper.MapRequestWithOptions(r, h.opts...)
+	}
+
+	if mapped.dict == nil {
+		return nil, mapped.err
+	}
+
+	// the handler adds fields, the middleware's Dict is kept intact
+	dict := make(Dict, len(mapped.dict))
+	for k, v := range mapped.dict {
+		dict[k] = v
+	}
+
+	return dict, mapped.err
+}
+
+func sameOptions(a, b []Option) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
 func addError(w http.ResponseWriter, err error) {
 	w.Header().Add("X-Error", err.Error())
 }
@@ -23,6 +23,7 @@
 package yare_test
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -71,7 +72,7 @@ func TestEchoHandler(t *testing.T) {
 			t.Parallel()
 			r := newRequest(tt.par)
 			w := httptest.NewRecorder()
-			handler := yare.EchoHander(tt.par.method == http.MethodPost)
+			handler := yare.EchoHandler(tt.par.method == http.MethodPost)
 
 			handler.ServeHTTP(w, r)
 
@@ -88,13 +89,80 @@ func TestEchoHandler(t *testing.T) {
 	}
 }
 
+func TestMapper_EchoHandlerMaxBodySize(t *testing.T) {
+	t.Parallel()
+
+	m := yare.NewMapper()
+	m.MaxBodySize = 4
+
+	r := newRequest(par{method: http.MethodPost, body: "0123456789"})
+	r.ContentLength = -1
+
+	w := httptest.NewRecorder()
+
+	m.EchoHandler(true).ServeHTTP(w, r)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	data, _ := ioutil.ReadAll(resp.Body)
+	got, _ := yare.ParseJSON(data)
+
+	want := map[string]interface{}{"truncated": true, "captured": json.Number("4"), "size": json.Number("10")}
+	if !reflect.DeepEqual(got["capture"], want) {
+		t.Errorf("EchoHandler() capture = %v, want %v", got["capture"], want)
+	}
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+
+	return len(p), nil
+}
+
+func TestMapper_EchoHandlerDrainLimit(t *testing.T) {
+	t.Parallel()
+
+	m := yare.NewMapper()
+	m.MaxBodySize = 4
+
+	r := newRequest(par{method: http.MethodPost})
+	r.Body, r.ContentLength = ioutil.NopCloser(zeroReader{}), -1
+
+	w := httptest.NewRecorder()
+
+	m.EchoHandler(true).ServeHTTP(w, r)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	if v := resp.Header.Get("Connection"); v != "close" {
+		t.Errorf("EchoHandler() Connection = %q, want close", v)
+	}
+
+	data, _ := ioutil.ReadAll(resp.Body)
+	got, _ := yare.ParseJSON(data)
+
+	want := map[string]interface{}{"truncated": true, "captured": json.Number("4")}
+	if !reflect.DeepEqual(got["capture"], want) {
+		t.Errorf("EchoHandler() capture = %v, want %v", got["capture"], want)
+	}
+}
+
 func TestEchoHandlerError(t *testing.T) {
 	t.Parallel()
 
+	cty := registerJSON(t)
+
 	tests := []struct {
 		name   string
 		par    par
 		status int
+		errors []interface{}
 	}{
 		{
 			name: "parameters", par: par{
@@ -102,6 +170,21 @@ func TestEchoHandlerError(t *testing.T) {
 				header: kv{"Content-Type": "application/x-www-form-urlencoded"}, body: "%1",
 			},
 			status: http.StatusBadRequest,
+			errors: []interface{}{map[string]interface{}{
+				"section": "form", "content_type": "application/x-www-form-urlencoded",
+				"message": `invalid URL escape "%1"`,
+			}},
+		},
+		{
+			name: "body", par: par{
+				method: http.MethodPost,
+				header: kv{"Content-Type": cty}, body: `{"foo":}`,
+			},
+			status: http.StatusBadRequest,
+			errors: []interface{}{map[string]interface{}{
+				"section": "body", "content_type": cty, "offset": json.Number("8"),
+				"message": "invalid character '}' looking for beginning of value",
+			}},
 		},
 	}
 	for _, tt := range tests {
@@ -120,6 +203,13 @@ func TestEchoHandlerError(t *testing.T) {
 			if resp.StatusCode != tt.status {
 				t.Errorf("EchoHandler() status = %v, want %v", resp.StatusCode, tt.status)
 			}
+
+			data, _ := ioutil.ReadAll(resp.Body)
+			got, _ := yare.ParseJSON(data)
+
+			if !reflect.DeepEqual(got["errors"], tt.errors) {
+				t.Errorf("EchoHandler() errors = %v, want %v", got["errors"], tt.errors)
+			}
 		})
 	}
 }
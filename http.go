@@ -30,33 +30,78 @@ import (
 	"strings"
 )
 
-// MapRequest creates Dict from various request attributes.
+// MapRequest creates Dict from various request attributes using DefaultMapper.
 func MapRequest(r *http.Request, body bool) (Dict, error) {
+	return DefaultMapper.MapRequest(r, body)
+}
+
+// MapResponse creates Dict from various response attributes using DefaultMapper.
+func MapResponse(r *http.Response, body bool) (Dict, error) {
+	return DefaultMapper.MapResponse(r, body)
+}
+
+// MapRequestWithOptions creates Dict from various request attributes using DefaultMapper and the given options.
+func MapRequestWithOptions(r *http.Request, opts ...Option) (Dict, error) {
+	return DefaultMapper.MapRequestWithOptions(r, opts...)
+}
+
+// MapResponseWithOptions creates Dict from various response attributes using DefaultMapper and the given options.
+func MapResponseWithOptions(r *http.Response, opts ...Option) (Dict, error) {
+	return DefaultMapper.MapResponseWithOptions(r, opts...)
+}
+
+// MapRequest creates Dict from various request attributes.
+func (m *Mapper) MapRequest(r *http.Request, body bool) (Dict, error) {
+	return m.MapRequestWithOptions(r, WithBody(body))
+}
+
+// MapResponse creates Dict from various response attributes.
+func (m *Mapper) MapResponse(r *http.Response, body bool) (Dict, error) {
+	return m.MapResponseWithOptions(r, WithBody(body))
+}
+
+// MapRequestWithOptions creates Dict from various request attributes using the given options.
+func (m *Mapper) MapRequestWithOptions(r *http.Request, opts ...Option) (Dict, error) {
+	o := m.newOptions(opts)
+	if o.err != nil {
+		return nil, o.err
+	}
+
 	var err error
 
 	out := make(Dict)
-	errs := []error{}
+	errs := new(MapError)
 
 	// HTTP protocol
 	out["version"] = r.Proto
 	out["method"] = r.Method
 
 	// HTTP headers
-	if d := omitEmpty(MapValues(r.Header)); d != nil {
-		out["headers"] = canonicalHeaderKeys(d)
+	if o.headers {
+		if d := omitEmpty(MapValues(r.Header)); d != nil {
+			out["headers"] = canonicalHeaderKeys(d)
+		}
 	}
 
 	// Cookies
-	if d := omitEmpty(mapCookies(r.Cookies())); d != nil {
-		out["cookies"] = d
+	if o.cookies {
+		if d := omitEmpty(mapCookies(r.Cookies())); d != nil {
+			out["cookies"] = d
+		}
+	}
+
+	if o.raw {
+		out["raw"] = mapRawRequest(r)
 	}
 
 	// query and form params
 	u := r.URL
 	out["path"] = u.Path
 
-	if d := omitEmpty(MapValues(u.Query())); d != nil {
-		out["query"] = d
+	if o.query {
+		if d := omitEmpty(MapValues(u.Query())); d != nil {
+			out["query"] = d
+		}
 	}
 
 	if err = r.ParseForm(); err == nil {
@@ -64,71 +109,95 @@ func MapRequest(r *http.Request, body bool) (Dict, error) {
 			out["form"] = d
 		}
 	} else {
-		errs = append(errs, err)
+		errs.add("form", r.Header.Get("Content-Type"), err)
 	}
 
 	// request body
-	if body {
-		if v, err := parseRequestBody(r); err == nil {
-			if v != nil {
-				out["body"] = v
-			}
-		} else {
-			errs = append(errs, err)
+	if o.body {
+		if err := o.mapRequestBody(out, r); err != nil {
+			errs.add("body", r.Header.Get("Content-Type"), err)
 		}
 	}
 
 	// authorization
-	if v, err := parseAuthorizationHeader(r.Header.Get("Authorization")); err == nil {
+	if v, err := o.parseAuthorizationHeader(r.Header.Get("Authorization")); err == nil {
 		if v != nil {
 			out["authorization"] = v
 		}
 	} else {
-		errs = append(errs, err)
+		errs.add("authorization", "", err)
 	}
 
-	if len(errs) > 0 {
-		return out, wrapError(errs...)
+	// connection, after the body read because of trailers
+	if o.conn {
+		if d := omitEmpty(mapConnection(r)); d != nil {
+			out["connection"] = d
+		}
+
+		if r.TLS != nil {
+			out["tls"] = mapTLS(r.TLS)
+		}
 	}
 
-	return out, nil
+	// wire level request, after the body read
+	if len(o.wire) != 0 {
+		if d := mapWire(r, o.wire); d != nil {
+			out["wire"] = d
+		}
+	}
+
+	o.redactAll(out)
+
+	return out, errs.errorOrNil()
 }
 
-// MapResponse creates Dict from various response attributes.
-func MapResponse(r *http.Response, body bool) (Dict, error) {
+// MapResponseWithOptions creates Dict from various response attributes using the given options.
+func (m *Mapper) MapResponseWithOptions(r *http.Response, opts ...Option) (Dict, error) {
+	o := m.newOptions(opts)
+	if o.err != nil {
+		return nil, o.err
+	}
+
 	out := make(Dict)
-	errs := []error{}
+	errs := new(MapError)
 
 	// HTTP protocol
 	out["version"] = r.Proto
 	out["status"] = r.StatusCode
 
 	// HTTP headers
-	if d := omitEmpty(MapValues(r.Header)); d != nil {
-		out["headers"] = canonicalHeaderKeys(d)
+	if o.headers {
+		if d := omitEmpty(MapValues(r.Header)); d != nil {
+			out["headers"] = canonicalHeaderKeys(d)
+		}
 	}
 
 	// Cookies
-	if d := omitEmpty(mapCookies(r.Cookies())); d != nil {
-		out["cookies"] = d
-	}
+	if o.cookies {
+		d, cerrs := mapSetCookies(r.Header.Values("Set-Cookie"))
+		if d = omitEmpty(d); d != nil {
+			out["cookies"] = d
+		}
 
-	// request body
-	if body {
-		if v, err := parseResponseBody(r); err == nil {
-			if v != nil {
-				out["body"] = v
-			}
-		} else {
-			errs = append(errs, err)
+		for _, err := range cerrs {
+			errs.add("cookies", "", err)
 		}
 	}
 
-	if len(errs) > 0 {
-		return out, wrapError(errs...)
+	if o.raw {
+		out["raw"] = mapRawResponse(r)
 	}
 
-	return out, nil
+	// response body
+	if o.body {
+		if err := o.mapResponseBody(out, r); err != nil {
+			errs.add("body", r.Header.Get("Content-Type"), err)
+		}
+	}
+
+	o.redactAll(out)
+
+	return out, errs.errorOrNil()
 }
 
 func mapCookies(cookies []*http.Cookie) Dict {
@@ -145,7 +214,7 @@ func mapCookies(cookies []*http.Cookie) Dict {
 	return out
 }
 
-func parseAuthorizationHeader(hdr string) (Dict, error) {
+func (o *options) parseAuthorizationHeader(hdr string) (Dict, error) {
 	if len(hdr) == 0 {
 		return nil, nil
 	}
@@ -159,7 +228,7 @@ func parseAuthorizationHeader(hdr string) (Dict, error) {
 		credentials = f[1]
 	}
 
-	v, err := parseAuth(scheme, credentials)
+	v, err := o.parseAuth(scheme, credentials)
 	if err != nil {
 		return nil, err
 	}
@@ -174,44 +243,148 @@ func parseAuthorizationHeader(hdr string) (Dict, error) {
 	return Dict{scheme: val}, nil
 }
 
-func parseRequestBody(r *http.Request) (Dict, error) {
-	reader, body, err := wrapReader(r.Body)
+func (o *options) mapRequestBody(out Dict, r *http.Request) error {
+	if r.Body == nil {
+		return nil
+	}
+
+	reader, body, truncated, err := captureReader(r.Body, o.maxBodySize)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	r.Body = reader
 
-	if len(body) == 0 {
-		return nil, nil
-	}
+	if truncated {
+		out["capture"] = mapCapture(len(body), r.ContentLength)
 
-	out, err := parseContent(r.Header.Get("Content-Type"), body)
-	if err != nil {
-		return nil, err
+		return o.mapTruncatedBody(out, body)
 	}
 
-	return omitEmpty(out), nil
+	return o.mapBody(out, r.Header, body)
 }
 
-func parseResponseBody(r *http.Response) (Dict, error) {
-	reader, body, err := wrapReader(r.Body)
+func (o *options) mapResponseBody(out Dict, r *http.Response) error {
+	if r.Body == nil {
+		return nil
+	}
+
+	reader, body, truncated, err := captureReader(r.Body, o.maxBodySize)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	r.Body = reader
 
+	if truncated {
+		out["capture"] = mapCapture(len(body), r.ContentLength)
+
+		return o.mapTruncatedBody(out, body)
+	}
+
+	return o.mapBody(out, r.Header, body)
+}
+
+func (o *options) mapBody(out Dict, header http.Header, body []byte) error {
 	if len(body) == 0 {
-		return nil, nil
+		return nil
+	}
+
+	if codings := contentCodings(header.Values("Content-Encoding")); len(codings) != 0 {
+		decoded, err := decodeContent(codings, body, o.maxDecodedSize)
+		if err != nil {
+			out["encoding"] = Dict{"codings": codings, "compressed": len(body)}
+
+			return newFieldError("encoding", header.Get("Content-Type"), err)
+		}
+
+		out["encoding"] = Dict{"codings": codings, "compressed": len(body), "uncompressed": len(decoded)}
+		body = decoded
+	}
+
+	cty := header.Get("Content-Type")
+
+	var cerr error
+
+	if mt, params, err := parseMediaType(cty); err == nil && len(params) != 0 {
+		out["media"] = Dict{"type": mt, "parameters": mapParams(params)}
+
+		// multipart parts have their own charset, undecodable content is shown by the fallback
+		if !isMultipart(cty) {
+			if decoded, err := decodeCharset(params, body); err == nil {
+				body = decoded
+			} else {
+				cerr = newFieldError("charset", cty, err)
+			}
+		}
+	}
+
+	if isMultipart(cty) {
+		parts, err := o.parseMultipart(cty, body)
+		if len(parts) != 0 {
+			out["multipart"] = parts
+		}
+
+		return err
+	}
+
+	var (
+		v   Dict
+		err error
+	)
+
+	// a missing Content-Type is not an error, the body is shown by the fallback
+	if len(cty) != 0 && cerr == nil {
+		v, err = o.parseContent(cty, body)
+	}
+
+	if v = omitEmpty(v); v == nil && o.fallback != nil {
+		fv, ferr := o.fallback(body)
+		if err == nil {
+			err = ferr
+		}
+
+		v = omitEmpty(fv)
+	}
+
+	if v != nil {
+		out["body"] = v
+	}
+
+	if cerr != nil {
+		return cerr
 	}
 
-	out, err := parseContent(r.Header.Get("Content-Type"), body)
 	if err != nil {
-		return nil, err
+		return newFieldError("body", cty, err)
 	}
 
-	return omitEmpty(out), nil
+	return nil
+}
+
+// mapTruncatedBody maps the captured prefix of a body exceeding MaxBodySize.
+// The prefix is not decoded nor parsed, only the fallback representation is used.
+func (o *options) mapTruncatedBody(out Dict, body []byte) error {
+	if o.fallback == nil {
+		return nil
+	}
+
+	v, err := o.fallback(body)
+	if v = omitEmpty(v); v != nil {
+		out["body"] = v
+	}
+
+	return err
+}
+
+func mapParams(params map[string]string) Dict {
+	out := make(Dict, len(params))
+
+	for k, v := range params {
+		out[k] = v
+	}
+
+	return out
 }
 
 func wrapReader(r io.ReadCloser) (io.ReadCloser, []byte, error) {
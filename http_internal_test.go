@@ -116,7 +116,7 @@ func Test_parseAuthorizationHeader(t *testing.T) {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			got, err := parseAuthorizationHeader(tt.args.hdr)
+			got, err := DefaultMapper.newOptions(nil).parseAuthorizationHeader(tt.args.hdr)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("parseAuthorizationHeader() error = %v, wantErr %v", err, tt.wantErr)
 
@@ -129,7 +129,7 @@ func Test_parseAuthorizationHeader(t *testing.T) {
 	}
 }
 
-func Test_parseRequestBody(t *testing.T) {
+func Test_mapRequestBody(t *testing.T) {
 	t.Parallel()
 
 	name := t.Name()
@@ -157,12 +157,12 @@ func Test_parseRequestBody(t *testing.T) {
 		{
 			name: "normal",
 			args: args{r: httptest.NewRequest("POST", "http://localhost", bytes.NewBufferString("Ready"))},
-			want: Dict{"dummy": "Ready"},
+			want: Dict{"body": Dict{"dummy": "Ready"}},
 		},
 		{
 			name: "empty",
 			args: args{r: httptest.NewRequest("POST", "http://localhost", bytes.NewBufferString(""))},
-			want: nil,
+			want: Dict{},
 		},
 	}
 	for _, tt := range tests {
@@ -170,20 +170,24 @@ func Test_parseRequestBody(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 			tt.args.r.Header.Set("Content-Type", "test/"+name)
-			got, err := parseRequestBody(tt.args.r)
+			got := make(Dict)
+			err := DefaultMapper.newOptions(nil).mapRequestBody(got, tt.args.r)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("parseRequestBody() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("mapRequestBody() error = %v, wantErr %v", err, tt.wantErr)
 
 				return
 			}
+			if tt.wantErr {
+				return
+			}
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("parseRequestBody() = %v, want %v", got, tt.want)
+				t.Errorf("mapRequestBody() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
-func Test_parseResponseBody(t *testing.T) {
+func Test_mapResponseBody(t *testing.T) {
 	t.Parallel()
 
 	name := t.Name()
@@ -213,7 +217,7 @@ func Test_parseResponseBody(t *testing.T) {
 			name: "normal",
 			args: args{body: "Hi"},
 			cty:  "test/" + name,
-			want: Dict{"dummy": "Hi"},
+			want: Dict{"body": Dict{"dummy": "Hi"}},
 		},
 		{
 			name:    "error",
@@ -233,14 +237,18 @@ func Test_parseResponseBody(t *testing.T) {
 			resp.Header.Set("Content-Type", tt.cty)
 			defer resp.Body.Close()
 
-			got, err := parseResponseBody(resp)
+			got := make(Dict)
+			err := DefaultMapper.newOptions(nil).mapResponseBody(got, resp)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("parseResponseBody() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("mapResponseBody() error = %v, wantErr %v", err, tt.wantErr)
 
 				return
 			}
+			if tt.wantErr {
+				return
+			}
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("parseResponseBody() = %v, want %v", got, tt.want)
+				t.Errorf("mapResponseBody() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -381,3 +389,47 @@ func Test_canonicalHeaderKeys(t *testing.T) {
 		})
 	}
 }
+
+func Test_captureReader(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		max       int64
+		data      []byte
+		truncated bool
+		wantErr   bool
+	}{
+		{name: "unlimited", max: 0, data: []byte("dummy")},
+		{name: "fits", max: 5, data: []byte("dummy")},
+		{name: "truncated", max: 2, data: []byte("du"), truncated: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			reader, data, truncated, err := captureReader(ioutil.NopCloser(bytes.NewBufferString("dummy")), tt.max)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("captureReader() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+
+			if !reflect.DeepEqual(data, tt.data) {
+				t.Errorf("captureReader() data = %v, want %v", data, tt.data)
+			}
+
+			if truncated != tt.truncated {
+				t.Errorf("captureReader() truncated = %v, want %v", truncated, tt.truncated)
+			}
+
+			if all, _ := ioutil.ReadAll(reader); string(all) != "dummy" {
+				t.Errorf("captureReader() reader = %s, want dummy", all)
+			}
+		})
+	}
+
+	if _, _, _, err := captureReader(ioutil.NopCloser(zeroErrReader{errDummy}), 4); err == nil {
+		t.Error("captureReader() error is nil")
+	}
+}
@@ -24,6 +24,9 @@ package yare_test
 
 import (
 	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -166,8 +169,9 @@ func TestMapRequestError(t *testing.T) {
 	scheme := registerAuth(t)
 
 	tests := []struct {
-		name string
-		par  par
+		name    string
+		par     par
+		section string
 	}{
 		{
 			name: "parameters", par: par{
@@ -175,6 +179,7 @@ func TestMapRequestError(t *testing.T) {
 				header: kv{"Content-Type": "application/x-www-form-urlencoded"},
 				body:   "%1",
 			},
+			section: "form",
 		},
 		{
 			name: "body", par: par{
@@ -182,12 +187,14 @@ func TestMapRequestError(t *testing.T) {
 				header: kv{"Content-Type": cty},
 				body:   "{\"foo\"",
 			},
+			section: "body",
 		},
 		{
 			name: "authorization", par: par{
 				method: "GET",
 				header: kv{"Authorization": scheme + " dummy"},
 			},
+			section: "authorization",
 		},
 	}
 	for _, tt := range tests {
@@ -197,11 +204,55 @@ func TestMapRequestError(t *testing.T) {
 			_, err := yare.MapRequest(newRequest(tt.par), tt.par.method == http.MethodPost)
 			if err == nil {
 				t.Error("MapRequest() error is nil")
+
+				return
+			}
+
+			if !errors.Is(err, yare.ErrParse) {
+				t.Errorf("MapRequest() error = %v, want ErrParse", err)
+			}
+
+			var merr *yare.MapError
+			if !errors.As(err, &merr) || len(merr.Errors) != 1 {
+				t.Errorf("MapRequest() error = %v, want MapError", err)
+
+				return
+			}
+
+			if merr.Errors[0].Section != tt.section {
+				t.Errorf("MapError section = %v, want %v", merr.Errors[0].Section, tt.section)
 			}
 		})
 	}
 }
 
+func TestMapRequestErrorUnwrap(t *testing.T) {
+	t.Parallel()
+
+	m := yare.NewMapper()
+	_ = m.RegisterContentType("application/json", yare.ParseJSON)
+
+	r := newRequest(par{method: http.MethodPost, header: kv{"Content-Type": "application/json"}, body: `{"foo":1x}`})
+
+	_, err := m.MapRequest(r, true)
+
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Errorf("MapRequest() error = %v, want json.SyntaxError", err)
+	}
+
+	var ferr *yare.FieldError
+	if !errors.As(err, &ferr) {
+		t.Errorf("MapRequest() error = %v, want FieldError", err)
+
+		return
+	}
+
+	if ferr.Offset != 9 || ferr.ContentType != "application/json" {
+		t.Errorf("FieldError = %+v", ferr)
+	}
+}
+
 func TestMapResponse(t *testing.T) {
 	t.Parallel()
 
@@ -249,6 +300,169 @@ func TestMapResponse(t *testing.T) {
 	}
 }
 
+func gzipped(in string) string {
+	var buff bytes.Buffer
+
+	w := gzip.NewWriter(&buff)
+	_, _ = w.Write([]byte(in))
+	_ = w.Close()
+
+	return buff.String()
+}
+
+func TestMapper_ContentEncoding(t *testing.T) {
+	t.Parallel()
+
+	cty := registerJSON(t)
+	body := gzipped(`{"foo":"bar"}`)
+	header := kv{"Content-Type": cty, "Content-Encoding": "gzip"}
+
+	req, err := yare.MapRequest(newRequest(par{method: http.MethodPost, body: body, header: header}), true)
+	if err != nil {
+		t.Errorf("MapRequest() error = %v", err)
+	}
+
+	resp := newResponse(par{body: body, header: header})
+	defer resp.Body.Close()
+
+	res, err := yare.MapResponse(resp, true)
+	if err != nil {
+		t.Errorf("MapResponse() error = %v", err)
+	}
+
+	for _, got := range []yare.Dict{req, res} {
+		if want := (yare.Dict{"foo": "bar"}); !reflect.DeepEqual(got["body"], want) {
+			t.Errorf("body = %v, want %v", got["body"], want)
+		}
+
+		want := yare.Dict{"codings": []string{"gzip"}, "compressed": len(body), "uncompressed": 13}
+		if !reflect.DeepEqual(got["encoding"], want) {
+			t.Errorf("encoding = %v, want %v", got["encoding"], want)
+		}
+	}
+
+	m := yare.NewMapper()
+	m.MaxDecodedSize = 10
+	_ = m.RegisterContentType(cty, yare.ParseJSON)
+
+	if _, err := m.MapRequest(newRequest(par{method: http.MethodPost, body: body, header: header}), true); err == nil {
+		t.Error("Mapper.MapRequest() error is nil")
+	}
+}
+
+func TestMapper_Charset(t *testing.T) {
+	t.Parallel()
+
+	var params map[string]string
+
+	m := yare.NewMapper()
+	_ = m.RegisterMediaType("application/json", func(in []byte, p map[string]string) (yare.Dict, error) {
+		params = p
+
+		return yare.ParseJSON(in)
+	})
+
+	header := kv{"Content-Type": "application/json; charset=ISO-8859-2; profile=test"}
+
+	got, err := m.MapRequest(newRequest(par{method: http.MethodPost, body: "{\"foo\":\"t\xfck\xf5r\"}", header: header}), true)
+	if err != nil {
+		t.Errorf("Mapper.MapRequest() error = %v", err)
+	}
+
+	if want := (yare.Dict{"foo": "tükőr"}); !reflect.DeepEqual(got["body"], want) {
+		t.Errorf("Mapper.MapRequest() body = %v, want %v", got["body"], want)
+	}
+
+	want := yare.Dict{"type": "application/json", "parameters": yare.Dict{"charset": "ISO-8859-2", "profile": "test"}}
+	if !reflect.DeepEqual(got["media"], want) {
+		t.Errorf("Mapper.MapRequest() media = %v, want %v", got["media"], want)
+	}
+
+	// the content is converted to UTF-8 already
+	if want := map[string]string{"profile": "test"}; !reflect.DeepEqual(params, want) {
+		t.Errorf("MediaParserFunc params = %v, want %v", params, want)
+	}
+
+	header = kv{"Content-Type": "application/json; charset=dummy"}
+	if _, err := m.MapRequest(newRequest(par{method: http.MethodPost, body: "{}", header: header}), true); err == nil {
+		t.Error("Mapper.MapRequest() error is nil")
+	}
+
+	// the undecoded body is shown by the fallback
+	m = yare.NewMapper()
+	m.Fallback = yare.RawBody(0)
+	header = kv{"Content-Type": "text/plain; charset=x-bogus"}
+
+	got, err = m.MapRequest(newRequest(par{method: http.MethodPost, body: "Hi", header: header}), true)
+	if err == nil {
+		t.Error("Mapper.MapRequest() error is nil")
+	}
+
+	if body, _ := got["body"].(yare.Dict); body["text"] != "Hi" {
+		t.Errorf("Mapper.MapRequest() body = %v, want fallback text Hi", got["body"])
+	}
+}
+
+func TestMapper_MaxBodySize(t *testing.T) {
+	t.Parallel()
+
+	m := yare.NewMapper()
+	m.MaxBodySize = 4
+	m.Fallback = yare.RawBody(yare.DefaultRawBodyMax)
+	_ = m.RegisterContentType("application/json", yare.ParseJSON)
+
+	body := `{"foo":"bar"}`
+	header := kv{"Content-Type": "application/json"}
+
+	r := newRequest(par{method: http.MethodPost, body: body, header: header})
+
+	req, err := m.MapRequest(r, true)
+	if err != nil {
+		t.Errorf("Mapper.MapRequest() error = %v", err)
+	}
+
+	if data, _ := ioutil.ReadAll(r.Body); string(data) != body {
+		t.Errorf("request body = %s, want %s", data, body)
+	}
+
+	resp := newResponse(par{body: body, header: header})
+	defer resp.Body.Close()
+
+	res, err := m.MapResponse(resp, true)
+	if err != nil {
+		t.Errorf("Mapper.MapResponse() error = %v", err)
+	}
+
+	if data, _ := ioutil.ReadAll(resp.Body); string(data) != body {
+		t.Errorf("response body = %s, want %s", data, body)
+	}
+
+	if want := (yare.Dict{"truncated": true, "captured": 4, "size": int64(len(body))}); !reflect.DeepEqual(req["capture"], want) {
+		t.Errorf("Mapper.MapRequest() capture = %v, want %v", req["capture"], want)
+	}
+
+	if want := (yare.Dict{"truncated": true, "captured": 4}); !reflect.DeepEqual(res["capture"], want) {
+		t.Errorf("Mapper.MapResponse() capture = %v, want %v", res["capture"], want)
+	}
+
+	for _, got := range []yare.Dict{req, res} {
+		if b, ok := got["body"].(yare.Dict); !ok || b["text"] != `{"fo` {
+			t.Errorf("body = %v, want captured prefix", got["body"])
+		}
+	}
+
+	r = newRequest(par{method: http.MethodPost, body: `{}`, header: header})
+
+	req, err = m.MapRequest(r, true)
+	if err != nil {
+		t.Errorf("Mapper.MapRequest() error = %v", err)
+	}
+
+	if _, ok := req["capture"]; ok {
+		t.Errorf("Mapper.MapRequest() capture = %v, want none", req["capture"])
+	}
+}
+
 func TestMapResposeError(t *testing.T) {
 	t.Parallel()
 
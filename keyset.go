@@ -0,0 +1,367 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// DefaultJWKSTimeout is the time limit of downloading a JSON Web Key Set document by LoadJWKS.
+const DefaultJWKSTimeout = 10 * time.Second
+
+var jwksClient = &http.Client{Timeout: DefaultJWKSTimeout}
+
+var (
+	errUnsupportedKey = errors.New("unsupported key type")
+	errNoMatchingKey  = errors.New("no matching key")
+	errInvalidJWK     = errors.New("invalid JWK")
+)
+
+type verificationKey struct {
+	kid string
+	alg string
+	key interface{}
+}
+
+// KeySet stores keys used for JWT signature verification.
+//
+// Supported keys are []byte (HS256, HS384, HS512), *rsa.PublicKey (RS256, RS384, RS512, PS256, PS384, PS512),
+// *ecdsa.PublicKey (ES256, ES384, ES512) and ed25519.PublicKey (EdDSA).
+// The zero value is an empty KeySet ready to use.
+type KeySet struct {
+	mu   sync.RWMutex
+	keys []verificationKey
+}
+
+// NewKeySet returns a new empty KeySet.
+func NewKeySet() *KeySet {
+	return new(KeySet)
+}
+
+// AddKey adds a verification key with optional key ID to the KeySet.
+//
+// Private keys are accepted as well, their public part will be used.
+func (s *KeySet) AddKey(kid string, key interface{}) error {
+	return s.add(kid, "", key)
+}
+
+func (s *KeySet) add(kid, alg string, key interface{}) error {
+	switch k := key.(type) {
+	case []byte, *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+	case string:
+		key = []byte(k)
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+		key = k.(crypto.Signer).Public()
+	default:
+		return fmt.Errorf("%w: %T", errUnsupportedKey, key)
+	}
+
+	s.mu.Lock()
+	s.keys = append(s.keys, verificationKey{kid: kid, alg: alg, key: key})
+	s.mu.Unlock()
+
+	return nil
+}
+
+// AddJWKS adds all supported keys from a JSON Web Key Set document to the KeySet.
+//
+// Keys with unsupported key type or usage are silently ignored.
+func (s *KeySet) AddJWKS(data []byte) error {
+	var doc struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	for _, jwk := range doc.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			if errors.Is(err, errUnsupportedKey) {
+				continue
+			}
+
+			return err
+		}
+
+		if err := s.add(jwk.Kid, jwk.Alg, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LoadJWKS reads a JSON Web Key Set document from a file or from a http(s) URL and adds its keys to the KeySet.
+// Downloading the document fails after DefaultJWKSTimeout or on non 200 response status.
+func (s *KeySet) LoadJWKS(location string) error {
+	return s.LoadJWKSContext(context.Background(), location)
+}
+
+// LoadJWKSContext is like LoadJWKS, but the download of the document is canceled with ctx too.
+func (s *KeySet) LoadJWKSContext(ctx context.Context, location string) error {
+	var (
+		data []byte
+		err  error
+	)
+
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		data, err = readURL(ctx, location)
+	} else {
+		data, err = ioutil.ReadFile(location)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return s.AddJWKS(data)
+}
+
+func readURL(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := jwksClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// ParseJWT is a ParserFunc for parsing JWT to Dict with signature verification.
+//
+// In addition to ParseJWT output the Dict contains the key ID of the matching key (kid)
+// and the reason of failed validation (error).
+// Can use as RegisterContentType or RegisterAuthScheme parser argument.
+func (s *KeySet) ParseJWT(in []byte) (Dict, error) {
+	dict, err := ParseJWT(in)
+	if err != nil {
+		return nil, err
+	}
+
+	header, _ := dict["header"].(Dict)
+	alg, _ := header["alg"].(string)
+	kid, _ := header["kid"].(string)
+
+	matched, err := s.verify(string(in), alg, kid)
+	if len(matched) != 0 {
+		dict["kid"] = matched
+	}
+
+	if err != nil {
+		dict["error"] = err.Error()
+	} else {
+		dict["verified"] = true
+	}
+
+	return dict, nil
+}
+
+func (s *KeySet) verify(token, alg, kid string) (string, error) {
+	s.mu.RLock()
+	keys := s.keys
+	s.mu.RUnlock()
+
+	err := errNoMatchingKey
+
+	for _, k := range keys {
+		if (len(kid) != 0 && len(k.kid) != 0 && k.kid != kid) || !k.supports(alg) {
+			continue
+		}
+
+		key := k.key
+		p := jwt.Parser{UseJSONNumber: true, ValidMethods: []string{alg}}
+
+		_, err = p.Parse(token, func(*jwt.Token) (interface{}, error) {
+			return key, nil
+		})
+
+		if err == nil {
+			return k.kid, nil
+		}
+
+		// signature is valid, but claims are not (expired, not valid yet, etc)
+		var verr *jwt.ValidationError
+		if errors.As(err, &verr) && verr.Errors&jwt.ValidationErrorSignatureInvalid == 0 {
+			return k.kid, err
+		}
+	}
+
+	return "", err
+}
+
+func (k *verificationKey) supports(alg string) bool {
+	if len(k.alg) != 0 && k.alg != alg {
+		return false
+	}
+
+	switch k.key.(type) {
+	case []byte:
+		return strings.HasPrefix(alg, "HS")
+	case *rsa.PublicKey:
+		return strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "PS")
+	case *ecdsa.PublicKey:
+		return strings.HasPrefix(alg, "ES")
+	case ed25519.PublicKey:
+		return alg == "EdDSA"
+	default:
+		return false
+	}
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	K   string `json:"k"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (jwk *jsonWebKey) publicKey() (interface{}, error) {
+	switch jwk.Kty {
+	case "oct":
+		return jwk.decode(jwk.K)
+	case "RSA":
+		return jwk.rsaKey()
+	case "EC":
+		return jwk.ecdsaKey()
+	case "OKP":
+		return jwk.ed25519Key()
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedKey, jwk.Kty)
+	}
+}
+
+func (jwk *jsonWebKey) decode(str string) ([]byte, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(str, "="))
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("%w: %s", errInvalidJWK, jwk.Kid)
+	}
+
+	return data, nil
+}
+
+func (jwk *jsonWebKey) rsaKey() (interface{}, error) {
+	n, err := jwk.decode(jwk.N)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := jwk.decode(jwk.E)
+	if err != nil {
+		return nil, err
+	}
+
+	exp := new(big.Int).SetBytes(e)
+	if !exp.IsInt64() || exp.Int64() > int64(^uint32(0)>>1) {
+		return nil, fmt.Errorf("%w: %s", errInvalidJWK, jwk.Kid)
+	}
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+}
+
+func (jwk *jsonWebKey) ecdsaKey() (interface{}, error) {
+	var curve elliptic.Curve
+
+	switch jwk.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedKey, jwk.Crv)
+	}
+
+	x, err := jwk.decode(jwk.X)
+	if err != nil {
+		return nil, err
+	}
+
+	y, err := jwk.decode(jwk.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	if !curve.IsOnCurve(key.X, key.Y) {
+		return nil, fmt.Errorf("%w: %s", errInvalidJWK, jwk.Kid)
+	}
+
+	return key, nil
+}
+
+func (jwk *jsonWebKey) ed25519Key() (interface{}, error) {
+	if jwk.Crv != "Ed25519" {
+		return nil, fmt.Errorf("%w: %s", errUnsupportedKey, jwk.Crv)
+	}
+
+	x, err := jwk.decode(jwk.X)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(x) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: %s", errInvalidJWK, jwk.Kid)
+	}
+
+	return ed25519.PublicKey(x), nil
+}
@@ -0,0 +1,301 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/szkiba/yare"
+)
+
+type testKeys struct {
+	secret []byte
+	rsa    *rsa.PrivateKey
+	ecdsa  *ecdsa.PrivateKey
+	ed     ed25519.PrivateKey
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testKeys{secret: []byte("secret"), rsa: rsaKey, ecdsa: ecKey, ed: edKey}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	str, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return str
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (k *testKeys) jwks() string {
+	return fmt.Sprintf(`{"keys":[
+		{"kty":"oct","kid":"hs","k":%q},
+		{"kty":"RSA","kid":"rs","use":"sig","n":%q,"e":%q},
+		{"kty":"EC","kid":"es","crv":"P-256","x":%q,"y":%q},
+		{"kty":"OKP","kid":"ed","crv":"Ed25519","x":%q},
+		{"kty":"RSA","kid":"enc","use":"enc","n":"AQAB","e":"AQAB"},
+		{"kty":"unknown","kid":"unknown"}
+	]}`,
+		b64(k.secret),
+		b64(k.rsa.N.Bytes()), b64(big.NewInt(int64(k.rsa.E)).Bytes()),
+		b64(k.ecdsa.X.Bytes()), b64(k.ecdsa.Y.Bytes()),
+		b64(k.ed.Public().(ed25519.PublicKey)),
+	)
+}
+
+func TestKeySet_ParseJWT(t *testing.T) {
+	t.Parallel()
+
+	keys := newTestKeys(t)
+	other := newTestKeys(t)
+
+	static := yare.NewKeySet()
+	_ = static.AddKey("hs", keys.secret)
+	_ = static.AddKey("rs", keys.rsa)
+	_ = static.AddKey("es", &keys.ecdsa.PublicKey)
+	_ = static.AddKey("ed", keys.ed)
+
+	jwks := yare.NewKeySet()
+	if err := jwks.AddJWKS([]byte(keys.jwks())); err != nil {
+		t.Fatal(err)
+	}
+
+	valid := jwt.MapClaims{"sub": "joe"}
+	expired := jwt.MapClaims{"sub": "joe", "exp": time.Now().Add(-time.Hour).Unix()}
+	future := jwt.MapClaims{"sub": "joe", "nbf": time.Now().Add(time.Hour).Unix()}
+
+	tests := []struct {
+		name     string
+		token    string
+		verified bool
+		kid      string
+		err      string
+	}{
+		{name: "HS256", token: sign(t, jwt.SigningMethodHS256, "hs", keys.secret, valid), verified: true, kid: "hs"},
+		{name: "RS256", token: sign(t, jwt.SigningMethodRS256, "rs", keys.rsa, valid), verified: true, kid: "rs"},
+		{name: "PS384", token: sign(t, jwt.SigningMethodPS384, "", keys.rsa, valid), verified: true, kid: "rs"},
+		{name: "ES256", token: sign(t, jwt.SigningMethodES256, "es", keys.ecdsa, valid), verified: true, kid: "es"},
+		{name: "EdDSA", token: sign(t, jwt.SigningMethodEdDSA, "ed", keys.ed, valid), verified: true, kid: "ed"},
+		{name: "no kid", token: sign(t, jwt.SigningMethodHS512, "", keys.secret, valid), verified: true, kid: "hs"},
+		{name: "expired", token: sign(t, jwt.SigningMethodHS256, "hs", keys.secret, expired), kid: "hs", err: "Token is expired"},
+		{name: "not before", token: sign(t, jwt.SigningMethodHS256, "hs", keys.secret, future), kid: "hs", err: "Token is not valid yet"},
+		{
+			name: "bad signature", token: sign(t, jwt.SigningMethodRS256, "rs", other.rsa, valid),
+			err: rsa.ErrVerification.Error(),
+		},
+		{name: "unknown kid", token: sign(t, jwt.SigningMethodHS256, "foo", keys.secret, valid), err: "no matching key"},
+		{name: "none", token: sign(t, jwt.SigningMethodNone, "", jwt.UnsafeAllowNoneSignatureType, valid), err: "no matching key"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		for name, set := range map[string]*yare.KeySet{"static": static, "jwks": jwks} {
+			set := set
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				t.Parallel()
+
+				got, err := set.ParseJWT([]byte(tt.token))
+				if err != nil {
+					t.Errorf("KeySet.ParseJWT() error = %v", err)
+
+					return
+				}
+
+				if got["verified"] != tt.verified {
+					t.Errorf("KeySet.ParseJWT() verified = %v, want %v", got["verified"], tt.verified)
+				}
+
+				if kid, _ := got["kid"].(string); kid != tt.kid {
+					t.Errorf("KeySet.ParseJWT() kid = %v, want %v", kid, tt.kid)
+				}
+
+				if msg, _ := got["error"].(string); msg != tt.err {
+					t.Errorf("KeySet.ParseJWT() error = %v, want %v", msg, tt.err)
+				}
+			})
+		}
+	}
+}
+
+func TestKeySet_ParseJWTError(t *testing.T) {
+	t.Parallel()
+
+	if _, err := yare.NewKeySet().ParseJWT([]byte("invalid")); err == nil {
+		t.Error("KeySet.ParseJWT() error is nil")
+	}
+}
+
+func TestKeySet_AddKey(t *testing.T) {
+	t.Parallel()
+
+	if err := yare.NewKeySet().AddKey("foo", 42); err == nil {
+		t.Error("KeySet.AddKey() error is nil")
+	}
+
+	if err := yare.NewKeySet().AddKey("foo", "secret"); err != nil {
+		t.Errorf("KeySet.AddKey() error = %v", err)
+	}
+}
+
+func TestKeySet_AddJWKSError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		jwks string
+	}{
+		{name: "syntax", jwks: "{"},
+		{name: "oct", jwks: `{"keys":[{"kty":"oct","k":""}]}`},
+		{name: "rsa", jwks: `{"keys":[{"kty":"RSA","n":"!","e":"AQAB"}]}`},
+		{name: "ec", jwks: `{"keys":[{"kty":"EC","crv":"P-256","x":"AQAB","y":"AQAB"}]}`},
+		{name: "okp", jwks: `{"keys":[{"kty":"OKP","crv":"Ed25519","x":"AQAB"}]}`},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if err := yare.NewKeySet().AddJWKS([]byte(tt.jwks)); err == nil {
+				t.Error("KeySet.AddJWKS() error is nil")
+			}
+		})
+	}
+}
+
+func TestKeySet_LoadJWKSContext(t *testing.T) {
+	t.Parallel()
+
+	done := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := yare.NewKeySet().LoadJWKSContext(ctx, srv.URL)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("KeySet.LoadJWKSContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestKeySet_LoadJWKS(t *testing.T) {
+	t.Parallel()
+
+	keys := newTestKeys(t)
+	jwks := keys.jwks()
+
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	if err := ioutil.WriteFile(file, []byte(jwks), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/jwks.json" {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(jwks))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		location string
+		wantErr  bool
+	}{
+		{name: "file", location: file},
+		{name: "url", location: srv.URL + "/jwks.json"},
+		{name: "missing file", location: file + ".missing", wantErr: true},
+		{name: "missing url", location: srv.URL + "/missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			set := yare.NewKeySet()
+			if err := set.LoadJWKS(tt.location); (err != nil) != tt.wantErr {
+				t.Errorf("KeySet.LoadJWKS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			got, _ := set.ParseJWT([]byte(sign(t, jwt.SigningMethodES256, "es", keys.ecdsa, jwt.MapClaims{})))
+			if got["verified"] != true {
+				t.Errorf("KeySet.ParseJWT() = %v, want verified", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Mapper maps http.Request and http.Response properties to Dict.
+//
+// Each Mapper owns its own content type and authentication scheme parser registries,
+// so independent Mapper instances can be used side by side without interfering.
+// The zero value is an empty Mapper ready to use. A Mapper must not be copied after first use.
+type Mapper struct {
+	// Fallback is used for body content not parsed by any registered parser (or failed to parse),
+	// including body content without Content-Type.
+	// If nil, such body content is omitted. Must not be modified after first use.
+	Fallback ParserFunc
+
+	// MaxDecodedSize limits the size of body content after removing Content-Encoding (decompression),
+	// zero means DefaultMaxDecodedSize. Must not be modified after first use.
+	MaxDecodedSize int64
+
+	// MaxBodySize limits the number of body bytes buffered for mapping, zero means no limit.
+	// Larger bodies are reported as truncated and are not parsed, but the full original
+	// body remains readable by downstream handlers. Must not be modified after first use.
+	MaxBodySize int64
+
+	contentTypeMu      sync.Mutex
+	atomicContentTypes atomic.Value
+	authSchemeMu       sync.Mutex
+	atomicAuthSchemes  atomic.Value
+}
+
+// NewMapper returns a new Mapper with empty parser registries.
+func NewMapper() *Mapper {
+	return new(Mapper)
+}
+
+// DefaultMapper is the Mapper used by package level functions.
+var DefaultMapper = NewMapper()
+
+type contentType struct {
+	main   string
+	sub    string
+	parser MediaParserFunc
+}
+
+func (c *contentType) matches(main, sub string) bool {
+	if c.main != "*" && c.main != main {
+		return false
+	}
+
+	if strings.HasPrefix(c.sub, "*") {
+		return strings.HasSuffix(sub, c.sub[1:])
+	}
+
+	return strings.HasPrefix(sub, c.sub) || strings.HasSuffix(sub, c.sub)
+}
+
+type authScheme struct {
+	scheme string
+	parser ParserFunc
+}
+
+// RegisterContentType registers custom content parser for a given Content-Type.
+//
+// The parser is used for content types whose subtype has the registered subtype as prefix or suffix.
+// The "*" main type matches any main type, and a subtype starting with "*" (like "*+xml") matches by suffix only.
+func (m *Mapper) RegisterContentType(cty string, parser ParserFunc) error {
+	return m.RegisterMediaType(cty, parser.withParams())
+}
+
+// RegisterMediaType registers custom content parser for a given Content-Type.
+//
+// Unlike RegisterContentType, the parser receives the media type parameters too.
+// Matching rules are the same as in RegisterContentType.
+func (m *Mapper) RegisterMediaType(cty string, parser MediaParserFunc) error {
+	main, sub, err := parseContentType(cty)
+	if err != nil {
+		return err
+	}
+
+	m.contentTypeMu.Lock()
+	values, _ := m.atomicContentTypes.Load().([]contentType)
+	m.atomicContentTypes.Store(append(values, contentType{main, sub, parser}))
+	m.contentTypeMu.Unlock()
+
+	return nil
+}
+
+// UnregisterContentType removes all content parsers registered for a given Content-Type.
+//
+// Returns true if any parser was removed.
+func (m *Mapper) UnregisterContentType(cty string) (bool, error) {
+	main, sub, err := parseContentType(cty)
+	if err != nil {
+		return false, err
+	}
+
+	m.contentTypeMu.Lock()
+	defer m.contentTypeMu.Unlock()
+
+	values, _ := m.atomicContentTypes.Load().([]contentType)
+	kept := make([]contentType, 0, len(values))
+
+	for _, c := range values {
+		if c.main != main || c.sub != sub {
+			kept = append(kept, c)
+		}
+	}
+
+	m.atomicContentTypes.Store(kept)
+
+	return len(kept) != len(values), nil
+}
+
+// RegisterAuthScheme registers custom authentication credentials parser for a given scheme.
+func (m *Mapper) RegisterAuthScheme(scheme string, parser ParserFunc) {
+	m.authSchemeMu.Lock()
+	values, _ := m.atomicAuthSchemes.Load().([]authScheme)
+	m.atomicAuthSchemes.Store(append(values, authScheme{scheme, parser}))
+	m.authSchemeMu.Unlock()
+}
+
+// UnregisterAuthScheme removes all authentication credentials parsers registered for a given scheme.
+//
+// Returns true if any parser was removed.
+func (m *Mapper) UnregisterAuthScheme(scheme string) bool {
+	m.authSchemeMu.Lock()
+	defer m.authSchemeMu.Unlock()
+
+	values, _ := m.atomicAuthSchemes.Load().([]authScheme)
+	kept := make([]authScheme, 0, len(values))
+
+	for _, a := range values {
+		if a.scheme != scheme {
+			kept = append(kept, a)
+		}
+	}
+
+	m.atomicAuthSchemes.Store(kept)
+
+	return len(kept) != len(values)
+}
+
+func (m *Mapper) maxDecodedSize() int64 {
+	if m.MaxDecodedSize > 0 {
+		return m.MaxDecodedSize
+	}
+
+	return DefaultMaxDecodedSize
+}
+
+func (m *Mapper) parseContent(cty string, content []byte) (Dict, error) {
+	mt, params, err := parseMediaType(cty)
+	if err != nil {
+		return nil, err
+	}
+
+	main, sub := splitMediaType(mt)
+
+	contentTypes, _ := m.atomicContentTypes.Load().([]contentType)
+
+	for _, c := range contentTypes {
+		if c.matches(main, sub) {
+			if dict, err := c.parser(content, parserParams(params)); err != nil || dict != nil {
+				return dict, err
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+func (m *Mapper) parseAuth(scheme, credentials string) (Dict, error) {
+	authorizations, _ := m.atomicAuthSchemes.Load().([]authScheme)
+
+	for _, a := range authorizations {
+		if a.scheme == scheme {
+			if dict, err := a.parser([]byte(credentials)); err != nil || dict != nil {
+				return dict, err
+			}
+		}
+	}
+
+	return nil, nil
+}
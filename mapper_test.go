@@ -0,0 +1,127 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare_test
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/szkiba/yare"
+)
+
+func TestMapper(t *testing.T) {
+	t.Parallel()
+
+	cty := "test/" + t.Name()
+
+	foo := yare.NewMapper()
+	_ = foo.RegisterContentType(cty, func(in []byte) (yare.Dict, error) {
+		return yare.Dict{"foo": string(in)}, nil
+	})
+
+	bar := yare.NewMapper()
+	_ = bar.RegisterContentType(cty, func(in []byte) (yare.Dict, error) {
+		return yare.Dict{"bar": string(in)}, nil
+	})
+
+	tests := []struct {
+		name   string
+		mapper *yare.Mapper
+		want   interface{}
+	}{
+		{name: "foo", mapper: foo, want: yare.Dict{"foo": "Hi"}},
+		{name: "bar", mapper: bar, want: yare.Dict{"bar": "Hi"}},
+		{name: "default", mapper: yare.DefaultMapper, want: nil},
+		{name: "zero", mapper: &yare.Mapper{}, want: nil},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := newRequest(par{method: http.MethodPost, body: "Hi", header: kv{"Content-Type": cty}})
+			got, err := tt.mapper.MapRequest(r, true)
+			if err != nil {
+				t.Errorf("Mapper.MapRequest() error = %v", err)
+
+				return
+			}
+
+			if body, ok := got["body"]; ok != (tt.want != nil) || (ok && !reflect.DeepEqual(body, tt.want)) {
+				t.Errorf("Mapper.MapRequest() body = %v, want %v", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestMapper_UnregisterContentType(t *testing.T) {
+	t.Parallel()
+
+	cty := "test/" + t.Name()
+
+	m := yare.NewMapper()
+	_ = m.RegisterContentType(cty, yare.ParseJSON)
+
+	if _, err := m.UnregisterContentType("foo bar"); err == nil {
+		t.Error("Mapper.UnregisterContentType() error is nil")
+	}
+
+	if ok, err := m.UnregisterContentType(cty); err != nil || !ok {
+		t.Errorf("Mapper.UnregisterContentType() = %v, %v, want true", ok, err)
+	}
+
+	if ok, _ := m.UnregisterContentType(cty); ok {
+		t.Error("Mapper.UnregisterContentType() removed parser twice")
+	}
+
+	r := newRequest(par{method: http.MethodPost, body: `{"foo":"bar"}`, header: kv{"Content-Type": cty}})
+	if got, _ := m.MapRequest(r, true); got["body"] != nil {
+		t.Errorf("Mapper.MapRequest() body = %v, want nil", got["body"])
+	}
+}
+
+func TestMapper_UnregisterAuthScheme(t *testing.T) {
+	t.Parallel()
+
+	m := yare.NewMapper()
+	m.RegisterAuthScheme("Bearer", yare.ParseJWT)
+
+	if !m.UnregisterAuthScheme("Bearer") {
+		t.Error("Mapper.UnregisterAuthScheme() = false, want true")
+	}
+
+	if m.UnregisterAuthScheme("Bearer") {
+		t.Error("Mapper.UnregisterAuthScheme() removed parser twice")
+	}
+
+	r := newRequest(par{method: http.MethodGet, header: kv{"Authorization": "Bearer dummy"}})
+
+	got, err := m.MapRequest(r, false)
+	if err != nil {
+		t.Errorf("Mapper.MapRequest() error = %v", err)
+	}
+
+	if want := (yare.Dict{"Bearer": "dummy"}); !reflect.DeepEqual(got["authorization"], want) {
+		t.Errorf("Mapper.MapRequest() authorization = %v, want %v", got["authorization"], want)
+	}
+}
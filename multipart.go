@@ -0,0 +1,145 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// maxPartContent is the maximum size of text part content included in multipart Dict.
+const maxPartContent = 4096
+
+var errMissingBoundary = errors.New("missing multipart boundary")
+
+func isMultipart(cty string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(cty)), "multipart/")
+}
+
+func (o *options) parseMultipart(cty string, body []byte) ([]interface{}, error) {
+	_, params, err := mime.ParseMediaType(cty)
+	if err != nil {
+		return nil, newFieldError("multipart", cty, wrapError(err))
+	}
+
+	boundary := params["boundary"]
+	if len(boundary) == 0 {
+		return nil, newFieldError("multipart", cty, wrapError(errMissingBoundary))
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	parts := []interface{}{}
+	errs := new(MapError)
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF { // unwrapped io.EOF indicates the regular end of parts
+			break
+		}
+
+		if err != nil {
+			errs.add("multipart", cty, wrapError(err))
+
+			break
+		}
+
+		d, err := o.mapPart(part)
+		if err != nil {
+			errs.add("multipart", part.Header.Get("Content-Type"), err)
+		}
+
+		parts = append(parts, d)
+	}
+
+	return parts, errs.errorOrNil()
+}
+
+func (o *options) mapPart(part *multipart.Part) (Dict, error) {
+	defer part.Close()
+
+	out := make(Dict)
+
+	if name := part.FormName(); len(name) != 0 {
+		out["name"] = name
+	}
+
+	if filename := part.FileName(); len(filename) != 0 {
+		out["filename"] = filename
+	}
+
+	if d := omitEmpty(MapValues(part.Header)); d != nil {
+		out["headers"] = canonicalHeaderKeys(d)
+	}
+
+	content, err := ioutil.ReadAll(part)
+	if err != nil {
+		return out, err
+	}
+
+	detected := http.DetectContentType(content)
+
+	out["size"] = len(content)
+	out["type"] = detected
+	out["sha256"] = sha256Hex(content)
+
+	cty := part.Header.Get("Content-Type")
+
+	var cerr error
+
+	// undecodable content is kept as is
+	if _, params, err := parseMediaType(cty); err == nil {
+		if decoded, err := decodeCharset(params, content); err == nil {
+			content = decoded
+			detected = http.DetectContentType(content)
+		} else {
+			cerr = err
+		}
+	}
+
+	if len(content) <= maxPartContent && isText(detected, content) {
+		out["content"] = string(content)
+	}
+
+	if cerr != nil {
+		return out, cerr
+	}
+
+	if len(cty) != 0 && len(content) != 0 {
+		v, err := o.parseContent(cty, content)
+		if err != nil {
+			return out, err
+		}
+
+		if v = omitEmpty(v); v != nil {
+			out["body"] = v
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,188 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/szkiba/yare"
+)
+
+func newMultipartRequest(t *testing.T, cty string) *http.Request {
+	t.Helper()
+
+	var buff bytes.Buffer
+
+	w := multipart.NewWriter(&buff)
+
+	_ = w.WriteField("foo", "bar")
+
+	fw, _ := w.CreateFormFile("file", "hello.bin")
+	_, _ = fw.Write([]byte{0, 1, 2, 3})
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", `form-data; name="data"; filename="data.json"`)
+	h.Set("Content-Type", cty)
+	pw, _ := w.CreatePart(h)
+	_, _ = pw.Write([]byte(`{"foo":"bar"}`))
+
+	_ = w.Close()
+
+	return newRequest(par{
+		method: http.MethodPost, body: buff.String(),
+		header: kv{"Content-Type": w.FormDataContentType()},
+	})
+}
+
+func TestMapRequestMultipart(t *testing.T) {
+	t.Parallel()
+
+	cty := registerJSON(t)
+
+	got, err := yare.MapRequest(newMultipartRequest(t, cty), true)
+	if err != nil {
+		t.Errorf("MapRequest() error = %v", err)
+
+		return
+	}
+
+	want := []interface{}{
+		yare.Dict{
+			"name":    "foo",
+			"headers": yare.Dict{"Content-Disposition": `form-data; name="foo"`},
+			"size":    3,
+			"type":    "text/plain; charset=utf-8",
+			"sha256":  "fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9",
+			"content": "bar",
+		},
+		yare.Dict{
+			"name":     "file",
+			"filename": "hello.bin",
+			"headers": yare.Dict{
+				"Content-Disposition": `form-data; name="file"; filename="hello.bin"`,
+				"Content-Type":        "application/octet-stream",
+			},
+			"size":   4,
+			"type":   "application/octet-stream",
+			"sha256": "054edec1d0211f624fed0cbca9d4f9400b0e491c43742af2c5b0abebf0c990d8",
+		},
+		yare.Dict{
+			"name":     "data",
+			"filename": "data.json",
+			"headers": yare.Dict{
+				"Content-Disposition": `form-data; name="data"; filename="data.json"`,
+				"Content-Type":        cty,
+			},
+			"size":    13,
+			"type":    "text/plain; charset=utf-8",
+			"sha256":  "7a38bf81f383f69433ad6e900d35b3e2385593f76a7b7ab5d4355b8ba41ee24b",
+			"content": `{"foo":"bar"}`,
+			"body":    yare.Dict{"foo": "bar"},
+		},
+	}
+
+	if !reflect.DeepEqual(got["multipart"], want) {
+		t.Errorf("MapRequest() multipart = %v, want %v", got["multipart"], want)
+	}
+}
+
+func TestMapRequestMultipartError(t *testing.T) {
+	t.Parallel()
+
+	cty := registerJSON(t)
+
+	tests := []struct {
+		name string
+		r    *http.Request
+	}{
+		{
+			name: "boundary",
+			r: newRequest(par{
+				method: http.MethodPost, body: "dummy",
+				header: kv{"Content-Type": "multipart/form-data"},
+			}),
+		},
+		{
+			name: "malformed",
+			r: newRequest(par{
+				method: http.MethodPost, body: "dummy",
+				header: kv{"Content-Type": "multipart/form-data; boundary=foo"},
+			}),
+		},
+		{
+			name: "part",
+			r: func() *http.Request {
+				r := newMultipartRequest(t, cty)
+
+				return newRequest(par{
+					method: http.MethodPost,
+					body:   strings.Replace(readBody(r), `{"foo":"bar"}`, `{"foo"::}`, 1),
+					header: kv{"Content-Type": r.Header.Get("Content-Type")},
+				})
+			}(),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := yare.MapRequest(tt.r, true); err == nil {
+				t.Error("MapRequest() error is nil")
+			}
+		})
+	}
+}
+
+func TestMapRequestMultipartCharset(t *testing.T) {
+	t.Parallel()
+
+	got, err := yare.MapRequest(newMultipartRequest(t, "application/json; charset=x-bogus"), true)
+	if err == nil {
+		t.Error("MapRequest() error is nil")
+	}
+
+	parts, _ := got["multipart"].([]interface{})
+
+	if len(parts) != 3 {
+		t.Fatalf("MapRequest() multipart = %v, want 3 parts", got["multipart"])
+	}
+
+	// the undecoded content is kept
+	if part, _ := parts[2].(yare.Dict); part["content"] != `{"foo":"bar"}` {
+		t.Errorf("MapRequest() part = %v, want content", part)
+	}
+}
+
+func readBody(r *http.Request) string {
+	var buff bytes.Buffer
+
+	_, _ = buff.ReadFrom(r.Body)
+
+	return buff.String()
+}
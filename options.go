@@ -0,0 +1,254 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare
+
+import "fmt"
+
+// Option configures a single mapping performed by MapRequestWithOptions or MapResponseWithOptions.
+type Option func(*options)
+
+// RedactFunc modifies the mapped Dict in place before it is returned, typically to hide sensitive values.
+type RedactFunc func(out Dict)
+
+// options store the configuration of a single mapping.
+type options struct {
+	mapper *Mapper
+
+	body    bool
+	headers bool
+	cookies bool
+	query   bool
+	raw     bool
+	wire    string
+	conn    bool
+
+	maxBodySize    int64
+	maxDecodedSize int64
+
+	registered   bool
+	contentTypes []contentType
+	authSchemes  []authScheme
+	fallback     ParserFunc
+
+	redact []RedactFunc
+
+	err error
+}
+
+func (m *Mapper) newOptions(opts []Option) *options {
+	o := &options{
+		mapper:         m,
+		headers:        true,
+		cookies:        true,
+		query:          true,
+		maxBodySize:    m.MaxBodySize,
+		maxDecodedSize: m.maxDecodedSize(),
+		registered:     true,
+		fallback:       m.Fallback,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithBody enables or disables body mapping (disabled by default).
+func WithBody(enabled bool) Option {
+	return func(o *options) {
+		o.body = enabled
+	}
+}
+
+// WithHeaders enables or disables header mapping (enabled by default).
+func WithHeaders(enabled bool) Option {
+	return func(o *options) {
+		o.headers = enabled
+	}
+}
+
+// WithCookies enables or disables cookie mapping (enabled by default).
+func WithCookies(enabled bool) Option {
+	return func(o *options) {
+		o.cookies = enabled
+	}
+}
+
+// WithQuery enables or disables query parameter mapping (enabled by default).
+func WithQuery(enabled bool) Option {
+	return func(o *options) {
+		o.query = enabled
+	}
+}
+
+// WithConnection enables or disables the connection and tls sections of requests (disabled by default).
+func WithConnection(enabled bool) Option {
+	return func(o *options) {
+		o.conn = enabled
+	}
+}
+
+// WithRaw enables or disables the raw section (disabled by default), which lists every header field
+// and cookie occurrence in original order. Requests accepted by WireListener are listed as seen on the wire.
+func WithRaw(enabled bool) Option {
+	return func(o *options) {
+		o.raw = enabled
+	}
+}
+
+// WithWire sets the format of the wire section (WireRaw, WireHex or WireEscaped), empty disables it (default).
+// The wire section contains the request bytes recorded by WireListener, it is omitted for requests
+// not accepted by WireListener.
+func WithWire(format string) Option {
+	return func(o *options) {
+		switch format {
+		case "", WireRaw, WireHex, WireEscaped:
+			o.wire = format
+		default:
+			o.err = fmt.Errorf("%w: %s", ErrWireFormat, format)
+		}
+	}
+}
+
+// WithMaxBodySize overrides Mapper.MaxBodySize, zero means no limit.
+func WithMaxBodySize(max int64) Option {
+	return func(o *options) {
+		o.maxBodySize = max
+	}
+}
+
+// WithMaxDecodedSize overrides Mapper.MaxDecodedSize, zero means DefaultMaxDecodedSize.
+func WithMaxDecodedSize(max int64) Option {
+	return func(o *options) {
+		if max <= 0 {
+			max = DefaultMaxDecodedSize
+		}
+
+		o.maxDecodedSize = max
+	}
+}
+
+// WithContentType adds a content parser for a given Content-Type.
+//
+// Parsers added by options take precedence over the parsers registered in the Mapper.
+// Matching rules are the same as in RegisterContentType.
+func WithContentType(cty string, parser ParserFunc) Option {
+	return WithMediaType(cty, parser.withParams())
+}
+
+// WithMediaType adds a content parser for a given Content-Type, which receives the media type parameters too.
+//
+// Parsers added by options take precedence over the parsers registered in the Mapper.
+// Matching rules are the same as in RegisterContentType.
+func WithMediaType(cty string, parser MediaParserFunc) Option {
+	return func(o *options) {
+		main, sub, err := parseContentType(cty)
+		if err != nil {
+			o.err = err
+
+			return
+		}
+
+		o.contentTypes = append(o.contentTypes, contentType{main, sub, parser})
+	}
+}
+
+// WithAuthScheme adds an authorization scheme parser.
+//
+// Parsers added by options take precedence over the parsers registered in the Mapper.
+func WithAuthScheme(scheme string, parser ParserFunc) Option {
+	return func(o *options) {
+		o.authSchemes = append(o.authSchemes, authScheme{scheme, parser})
+	}
+}
+
+// WithRegisteredParsers enables or disables the content and authorization scheme parsers
+// registered in the Mapper (enabled by default).
+func WithRegisteredParsers(enabled bool) Option {
+	return func(o *options) {
+		o.registered = enabled
+	}
+}
+
+// WithFallback overrides Mapper.Fallback, nil disables the fallback body representation.
+func WithFallback(parser ParserFunc) Option {
+	return func(o *options) {
+		o.fallback = parser
+	}
+}
+
+// WithRedact adds a function to modify the mapped Dict before it is returned.
+// Functions are called in the order they were added.
+func WithRedact(fn RedactFunc) Option {
+	return func(o *options) {
+		o.redact = append(o.redact, fn)
+	}
+}
+
+func (o *options) parseContent(cty string, content []byte) (Dict, error) {
+	if len(o.contentTypes) != 0 {
+		mt, params, err := parseMediaType(cty)
+		if err != nil {
+			return nil, err
+		}
+
+		main, sub := splitMediaType(mt)
+
+		for _, c := range o.contentTypes {
+			if c.matches(main, sub) {
+				if dict, err := c.parser(content, parserParams(params)); err != nil || dict != nil {
+					return dict, err
+				}
+			}
+		}
+	}
+
+	if !o.registered {
+		return nil, nil
+	}
+
+	return o.mapper.parseContent(cty, content)
+}
+
+func (o *options) parseAuth(scheme, credentials string) (Dict, error) {
+	for _, a := range o.authSchemes {
+		if a.scheme == scheme {
+			if dict, err := a.parser([]byte(credentials)); err != nil || dict != nil {
+				return dict, err
+			}
+		}
+	}
+
+	if !o.registered {
+		return nil, nil
+	}
+
+	return o.mapper.parseAuth(scheme, credentials)
+}
+
+func (o *options) redactAll(out Dict) {
+	for _, fn := range o.redact {
+		fn(out)
+	}
+}
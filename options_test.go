@@ -0,0 +1,132 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare_test
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/szkiba/yare"
+)
+
+func TestMapRequestWithOptions(t *testing.T) {
+	t.Parallel()
+
+	m := yare.NewMapper()
+	_ = m.RegisterContentType("application/json", yare.ParseJSON)
+	m.RegisterAuthScheme("Basic", yare.ParseBasic)
+
+	upper := func(in []byte) (yare.Dict, error) {
+		return yare.Dict{"upper": string(in)}, nil
+	}
+
+	header := kv{"Content-Type": "application/json", "Cookie": "foo=bar", "Authorization": "Basic Zm9vOmJhcg=="}
+	p := par{method: http.MethodPost, url: "http://localhost/?foo=bar", body: `{"foo":"bar"}`, header: header}
+
+	tests := []struct {
+		name string
+		opts []yare.Option
+		key  string
+		want interface{}
+	}{
+		{name: "no body", key: "body", want: nil},
+		{name: "body", opts: []yare.Option{yare.WithBody(true)}, key: "body", want: yare.Dict{"foo": "bar"}},
+		{name: "headers", key: "headers", want: yare.Dict{
+			"Content-Type": "application/json", "Cookie": "foo=bar", "Authorization": "Basic Zm9vOmJhcg==",
+		}},
+		{name: "without headers", opts: []yare.Option{yare.WithHeaders(false)}, key: "headers", want: nil},
+		{name: "without cookies", opts: []yare.Option{yare.WithCookies(false)}, key: "cookies", want: nil},
+		{name: "without query", opts: []yare.Option{yare.WithQuery(false)}, key: "query", want: nil},
+		{
+			name: "content type", key: "body", want: yare.Dict{"upper": `{"foo":"bar"}`},
+			opts: []yare.Option{yare.WithBody(true), yare.WithContentType("application/json", upper)},
+		},
+		{
+			name: "without registered parsers", key: "body", want: nil,
+			opts: []yare.Option{yare.WithBody(true), yare.WithRegisteredParsers(false)},
+		},
+		{
+			name: "fallback", key: "body", want: yare.Dict{"upper": `{"foo":"bar"}`},
+			opts: []yare.Option{yare.WithBody(true), yare.WithRegisteredParsers(false), yare.WithFallback(upper)},
+		},
+		{
+			name: "auth scheme", key: "authorization", want: yare.Dict{"Basic": yare.Dict{"upper": "Zm9vOmJhcg=="}},
+			opts: []yare.Option{yare.WithAuthScheme("Basic", upper)},
+		},
+		{
+			name: "max body size", key: "capture", want: yare.Dict{"truncated": true, "captured": 2, "size": int64(13)},
+			opts: []yare.Option{yare.WithBody(true), yare.WithMaxBodySize(2)},
+		},
+		{
+			name: "redact", key: "path", want: "redacted",
+			opts: []yare.Option{yare.WithRedact(func(out yare.Dict) { out["path"] = "redacted" })},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := m.MapRequestWithOptions(newRequest(p), tt.opts...)
+			if err != nil {
+				t.Errorf("Mapper.MapRequestWithOptions() error = %v", err)
+
+				return
+			}
+
+			if !reflect.DeepEqual(got[tt.key], tt.want) {
+				t.Errorf("Mapper.MapRequestWithOptions() %s = %v, want %v", tt.key, got[tt.key], tt.want)
+			}
+		})
+	}
+}
+
+func TestMapResponseWithOptions(t *testing.T) {
+	t.Parallel()
+
+	resp := newResponse(par{body: `{"foo":"bar"}`, header: kv{"Content-Type": "application/json"}})
+	defer resp.Body.Close()
+
+	got, err := yare.MapResponseWithOptions(resp,
+		yare.WithBody(true),
+		yare.WithHeaders(false),
+		yare.WithContentType("application/json", yare.ParseJSON),
+	)
+	if err != nil {
+		t.Errorf("MapResponseWithOptions() error = %v", err)
+	}
+
+	want := yare.Dict{"version": "HTTP/1.1", "status": http.StatusOK, "body": yare.Dict{"foo": "bar"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MapResponseWithOptions() = %v, want %v", got, want)
+	}
+}
+
+func TestMapRequestWithOptionsError(t *testing.T) {
+	t.Parallel()
+
+	_, err := yare.MapRequestWithOptions(newRequest(par{}), yare.WithContentType("dummy/", yare.ParseJSON))
+	if err == nil {
+		t.Error("MapRequestWithOptions() error is nil")
+	}
+}
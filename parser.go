@@ -24,13 +24,14 @@ package yare
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"io"
 	"mime"
 	"strings"
-	"sync"
-	"sync/atomic"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/golang-jwt/jwt/v4"
 )
 
 // ParserFunc used to register custom body and authorization parsers.
@@ -45,45 +46,36 @@ func (p ParserFunc) Optional() ParserFunc {
 	}
 }
 
-type contentType struct {
-	main   string
-	sub    string
-	parser ParserFunc
-}
-
-type authScheme struct {
-	scheme string
-	parser ParserFunc
-}
+// MediaParserFunc used to register custom body parsers which need media type parameters (like version or profile).
+//
+// The content is already converted to UTF-8 according to the charset parameter,
+// so the charset parameter is not passed to the parser.
+type MediaParserFunc func(in []byte, params map[string]string) (Dict, error)
 
-var (
-	contentTypeMu      sync.Mutex
-	atomicContentTypes atomic.Value
-	authSchemeMu       sync.Mutex
-	atomicAutScheme    atomic.Value
-)
+func (p ParserFunc) withParams() MediaParserFunc {
+	if p == nil {
+		return nil
+	}
 
-// RegisterContentType registers custom content parser for a given Content-Type.
-func RegisterContentType(cty string, parser ParserFunc) error {
-	main, sub, err := parseContentType(cty)
-	if err != nil {
-		return err
+	return func(in []byte, _ map[string]string) (Dict, error) {
+		return p(in)
 	}
+}
 
-	contentTypeMu.Lock()
-	values, _ := atomicContentTypes.Load().([]contentType)
-	atomicContentTypes.Store(append(values, contentType{main, sub, parser}))
-	contentTypeMu.Unlock()
+// RegisterMediaType registers custom content parser with media type parameters
+// for a given Content-Type in DefaultMapper.
+func RegisterMediaType(cty string, parser MediaParserFunc) error {
+	return DefaultMapper.RegisterMediaType(cty, parser)
+}
 
-	return nil
+// RegisterContentType registers custom content parser for a given Content-Type in DefaultMapper.
+func RegisterContentType(cty string, parser ParserFunc) error {
+	return DefaultMapper.RegisterContentType(cty, parser)
 }
 
-// RegisterAuthScheme registers custom authentication credentials parser for a given scheme.
+// RegisterAuthScheme registers custom authentication credentials parser for a given scheme in DefaultMapper.
 func RegisterAuthScheme(scheme string, parser ParserFunc) {
-	authSchemeMu.Lock()
-	values, _ := atomicAutScheme.Load().([]authScheme)
-	atomicAutScheme.Store(append(values, authScheme{scheme, parser}))
-	authSchemeMu.Unlock()
+	DefaultMapper.RegisterAuthScheme(scheme, parser)
 }
 
 // ParseJWT is a ParserFunc for parsing JWT to Dict.
@@ -107,6 +99,25 @@ func ParseJWT(in []byte) (Dict, error) {
 	return Dict{"header": Dict(t.Header), "payload": claims, "verified": false}, nil
 }
 
+var errBasicCredentials = errors.New("missing colon in basic credentials")
+
+// ParseBasic is a ParserFunc for parsing Basic authentication credentials to Dict.
+//
+// Can use as RegisterAuthScheme parser argument.
+func ParseBasic(in []byte) (Dict, error) {
+	data, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(in)))
+	if err != nil {
+		return nil, wrapError(err)
+	}
+
+	idx := bytes.IndexByte(data, ':')
+	if idx < 0 {
+		return nil, wrapError(errBasicCredentials)
+	}
+
+	return Dict{"username": string(data[:idx]), "password": string(data[idx+1:])}, nil
+}
+
 // ParseJSON is a ParserFunc for parsing JSON string to Dict.
 //
 // Can use as RegisterContentType parser argument.
@@ -123,53 +134,66 @@ func ParseJSON(in []byte) (Dict, error) {
 	return out, nil
 }
 
-func parseContent(cty string, content []byte) (Dict, error) {
-	main, sub, err := parseContentType(cty)
-	if err != nil {
-		return nil, err
-	}
+// ParseNDJSON is a ParserFunc for parsing newline delimited JSON (JSON Lines) to Dict.
+//
+// The parsed values are stored as an array in the "records" key.
+// Can use as RegisterContentType parser argument.
+func ParseNDJSON(in []byte) (Dict, error) {
+	records := []interface{}{}
 
-	contentTypeMu.Lock()
-	contentTypes, _ := atomicContentTypes.Load().([]contentType)
-	contentTypeMu.Unlock()
+	d := json.NewDecoder(bytes.NewBuffer(in))
+	d.UseNumber()
 
-	for _, c := range contentTypes {
-		if c.main == main && (strings.HasPrefix(sub, c.sub) || strings.HasSuffix(sub, c.sub)) {
-			if dict, err := c.parser(content); err != nil || dict != nil {
-				return dict, err
-			}
+	for {
+		var v interface{}
+
+		err := d.Decode(&v)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, wrapError(err)
 		}
+
+		records = append(records, v)
 	}
 
-	return nil, nil
+	return Dict{"records": records}, nil
 }
 
-func parseAuth(scheme, credentials string) (Dict, error) {
-	authSchemeMu.Lock()
-	authorizations, _ := atomicAutScheme.Load().([]authScheme)
-	authSchemeMu.Unlock()
-
-	for _, a := range authorizations {
-		if a.scheme == scheme {
-			if dict, err := a.parser([]byte(credentials)); err != nil || dict != nil {
-				return dict, err
-			}
-		}
+func parseContentType(cty string) (string, string, error) {
+	mt, _, err := parseMediaType(cty)
+	if err != nil {
+		return "", "", err
 	}
 
-	return nil, nil
+	main, sub := splitMediaType(mt)
+
+	return main, sub, nil
 }
 
-func parseContentType(cty string) (string, string, error) {
-	mt, _, err := mime.ParseMediaType(cty)
+func parseMediaType(cty string) (string, map[string]string, error) {
+	mt, params, err := mime.ParseMediaType(cty)
 	if err != nil {
-		return "", "", wrapError(err)
+		return "", nil, wrapError(err)
 	}
 
+	return mt, params, nil
+}
+
+// parserParams returns the media type parameters passed to MediaParserFunc, the charset parameter removed.
+func parserParams(params map[string]string) map[string]string {
+	delete(params, "charset")
+
+	return params
+}
+
+func splitMediaType(mt string) (string, string) {
 	idx := strings.Index(mt, "/")
 	if idx <= 0 {
-		return "", "", nil
+		return "", ""
 	}
 
-	return mt[:idx], mt[idx+1:], nil
+	return mt[:idx], mt[idx+1:]
 }
@@ -67,7 +67,7 @@ func TestRegisterContentType(t *testing.T) {
 				return
 			}
 
-			got, err := parseContent(tt.args.cty, []byte("hello"))
+			got, err := DefaultMapper.parseContent(tt.args.cty, []byte("hello"))
 			if err != nil || !reflect.DeepEqual(got, Dict{"dummy": "hello"}) {
 				t.Errorf("Registration failed for %v", tt.args.cty)
 			}
@@ -102,7 +102,7 @@ func TestRegisterAuthScheme(t *testing.T) {
 			t.Parallel()
 			RegisterAuthScheme(tt.args.scheme, tt.args.parser)
 
-			got, err := parseAuth(tt.args.scheme, "hello")
+			got, err := DefaultMapper.parseAuth(tt.args.scheme, "hello")
 			if err != nil || !reflect.DeepEqual(got, Dict{"dummy": "hello"}) {
 				t.Errorf("Registration failed for %v", tt.args.scheme)
 			}
@@ -130,6 +130,7 @@ func registerTestContentParsers(t *testing.T) {
 	_ = RegisterContentType(name+"/WithNil", nopParser)
 	_ = RegisterContentType(name+"/WithNil", dummmyParser)
 	_ = RegisterContentType(name+"/WithErr", errParser)
+	_ = RegisterContentType("*/*+"+name+"Wild", dummmyParser)
 }
 
 func Test_parseContent(t *testing.T) {
@@ -162,6 +163,13 @@ func Test_parseContent(t *testing.T) {
 			name: "prefix", args: args{cty: main + "/Content+foo", content: "Hi"},
 			want: Dict{"dummy": "Hi"},
 		},
+		{
+			name: "wildcard", args: args{cty: "foo/bar+" + main + "Wild", content: "Hi"},
+			want: Dict{"dummy": "Hi"},
+		},
+		{
+			name: "wildcard suffix only", args: args{cty: "foo/" + main + "Wild+bar", content: "Hi"}, want: nil,
+		},
 		{
 			name: "unknown", args: args{cty: main + "/Unknown", content: "Hi"}, want: nil,
 		},
@@ -181,7 +189,7 @@ func Test_parseContent(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			got, err := parseContent(tt.args.cty, []byte(tt.args.content))
+			got, err := DefaultMapper.parseContent(tt.args.cty, []byte(tt.args.content))
 			if (err != nil) != tt.wantErr {
 				t.Errorf("parseContent() error = %v, wantErr %v", err, tt.wantErr)
 
@@ -260,7 +268,7 @@ func Test_parseAuth(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			got, err := parseAuth(tt.args.scheme, tt.args.credentials)
+			got, err := DefaultMapper.parseAuth(tt.args.scheme, tt.args.credentials)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("parseAuth() error = %v, wantErr %v", err, tt.wantErr)
 
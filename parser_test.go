@@ -83,6 +83,68 @@ func TestParseJWT(t *testing.T) {
 	}
 }
 
+func TestParseBasic(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		in string
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		want    yare.Dict
+		wantErr bool
+	}{
+		{
+			name: "normal",
+			args: args{in: "QWxhZGRpbjpvcGVuIHNlc2FtZQ=="},
+			want: yare.Dict{"username": "Aladdin", "password": "open sesame"},
+		},
+		{
+			name: "colon",
+			args: args{in: "Zm9vOmJhcjpiYXo="},
+			want: yare.Dict{"username": "foo", "password": "bar:baz"},
+		},
+		{
+			name: "empty password",
+			args: args{in: "Zm9vOg=="},
+			want: yare.Dict{"username": "foo", "password": ""},
+		},
+		{
+			name:    "no colon",
+			args:    args{in: "Zm9v"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid",
+			args:    args{in: "not base64"},
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			args:    args{in: ""},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := yare.ParseBasic([]byte(tt.args.in))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseBasic() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseBasic() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestParseJSON(t *testing.T) {
 	t.Parallel()
 
@@ -130,6 +192,57 @@ func TestParseJSON(t *testing.T) {
 	}
 }
 
+func TestParseNDJSON(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		in string
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		want    yare.Dict
+		wantErr bool
+	}{
+		{
+			name: "normal",
+			args: args{in: "{\"foo\":\"bar\"}\n{\"count\":1}\n[1,2]\n"},
+			want: yare.Dict{"records": []interface{}{
+				map[string]interface{}{"foo": "bar"},
+				map[string]interface{}{"count": json.Number("1")},
+				[]interface{}{json.Number("1"), json.Number("2")},
+			}},
+		},
+		{
+			name: "empty",
+			args: args{in: ""},
+			want: yare.Dict{"records": []interface{}{}},
+		},
+		{
+			name:    "invalid",
+			args:    args{in: "{\"foo\":\"bar\"}\nnot a json\n"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := yare.ParseNDJSON([]byte(tt.args.in))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseNDJSON() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseNDJSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestParserFunc_Optional(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,130 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// mapRawRequest creates the raw section of a request with every header field and cookie occurrence.
+//
+// Header fields are listed in wire order and casing if the request was recorded by WireListener,
+// otherwise in canonical form sorted by name (the wire flag tells which one).
+func mapRawRequest(r *http.Request) Dict {
+	lines, wire := wireHead(wireRequest(r))
+	if !wire {
+		lines = headerLines(r.Header, r.Host)
+	}
+
+	headers := make([]interface{}, 0, len(lines))
+	cookies := []interface{}{}
+
+	for _, line := range lines {
+		name, value := splitField(line, ':')
+
+		headers = append(headers, Dict{"name": name, "value": value})
+
+		if !strings.EqualFold(name, "Cookie") {
+			continue
+		}
+
+		for _, pair := range strings.Split(value, ";") {
+			if pair = strings.TrimSpace(pair); len(pair) != 0 {
+				cname, cvalue := splitField(pair, '=')
+				cookies = append(cookies, Dict{"name": cname, "value": cvalue})
+			}
+		}
+	}
+
+	return rawDict(headers, cookies, wire)
+}
+
+// mapRawResponse creates the raw section of a response with every header field and cookie occurrence.
+//
+// Header fields are listed in canonical form sorted by name, because http.Response does not keep the wire order.
+func mapRawResponse(r *http.Response) Dict {
+	lines := headerLines(r.Header, "")
+
+	headers := make([]interface{}, 0, len(lines))
+
+	for _, line := range lines {
+		name, value := splitField(line, ':')
+		headers = append(headers, Dict{"name": name, "value": value})
+	}
+
+	cookies := []interface{}{}
+
+	for _, line := range r.Header.Values("Set-Cookie") {
+		pair := strings.TrimSpace(strings.SplitN(line, ";", 2)[0])
+		name, value := splitField(pair, '=')
+
+		cookies = append(cookies, Dict{"name": name, "value": value})
+	}
+
+	return rawDict(headers, cookies, false)
+}
+
+func rawDict(headers, cookies []interface{}, wire bool) Dict {
+	out := Dict{"headers": headers, "wire": wire}
+
+	if len(cookies) != 0 {
+		out["cookies"] = cookies
+	}
+
+	return out
+}
+
+// headerLines returns header fields as "Name: value" lines sorted by name, starting with Host if not empty.
+func headerLines(header http.Header, host string) []string {
+	lines := []string{}
+
+	if len(host) != 0 {
+		lines = append(lines, "Host: "+host)
+	}
+
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		for _, v := range header[k] {
+			lines = append(lines, http.CanonicalHeaderKey(k)+": "+v)
+		}
+	}
+
+	return lines
+}
+
+func splitField(field string, sep byte) (string, string) {
+	idx := strings.IndexByte(field, sep)
+	if idx < 0 {
+		return "", strings.TrimSpace(field)
+	}
+
+	return field[:idx], strings.TrimSpace(field[idx+1:])
+}
@@ -0,0 +1,149 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare_test
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/szkiba/yare"
+)
+
+func field(name, value string) interface{} {
+	return yare.Dict{"name": name, "value": value}
+}
+
+func TestMapRequestRaw(t *testing.T) {
+	t.Parallel()
+
+	r := newRequest(par{method: http.MethodGet, header: kv{"x-foo": "bar"}})
+	r.Header.Add("Cookie", "a=1; a=2")
+	r.Header.Add("Cookie", "b=3")
+
+	got, err := yare.MapRequestWithOptions(r, yare.WithRaw(true))
+	if err != nil {
+		t.Errorf("MapRequestWithOptions() error = %v", err)
+	}
+
+	want := yare.Dict{
+		"wire": false,
+		"headers": []interface{}{
+			field("Host", "localhost"), field("Cookie", "a=1; a=2"), field("Cookie", "b=3"), field("X-Foo", "bar"),
+		},
+		"cookies": []interface{}{field("a", "1"), field("a", "2"), field("b", "3")},
+	}
+	if !reflect.DeepEqual(got["raw"], want) {
+		t.Errorf("MapRequestWithOptions() raw = %v, want %v", got["raw"], want)
+	}
+}
+
+func TestMapResponseRaw(t *testing.T) {
+	t.Parallel()
+
+	resp := newResponse(par{})
+	defer resp.Body.Close()
+
+	resp.Header.Add("Set-Cookie", "id=1; Path=/")
+	resp.Header.Add("Set-Cookie", "id=2; Path=/")
+
+	got, _ := yare.MapResponseWithOptions(resp, yare.WithRaw(true))
+
+	want := yare.Dict{
+		"wire":    false,
+		"headers": []interface{}{field("Set-Cookie", "id=1; Path=/"), field("Set-Cookie", "id=2; Path=/")},
+		"cookies": []interface{}{field("id", "1"), field("id", "2")},
+	}
+	if !reflect.DeepEqual(got["raw"], want) {
+		t.Errorf("MapResponseWithOptions() raw = %v, want %v", got["raw"], want)
+	}
+}
+
+func TestWireListener(t *testing.T) {
+	t.Parallel()
+
+	r := yare.NewRedactor()
+	_ = r.AddKey(yare.RedactHeaders, "x-secret")
+
+	srv := httptest.NewUnstartedServer(yare.NewMapper().EchoHandlerWithOptions(yare.WithRaw(true), yare.WithRedactor(r)))
+	srv.Listener = yare.WireListener(srv.Listener, 0)
+	srv.Config.ConnContext = yare.WireContext
+	srv.Start()
+
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer conn.Close()
+
+	_, _ = conn.Write([]byte("GET /first HTTP/1.1\r\nhost: localhost\r\nx-Foo: a\r\nX-SECRET: b\r\n\r\n" +
+		"POST /second HTTP/1.1\r\nHost: localhost\r\nContent-Length: 2\r\nx-bar:  c\r\n\r\nhi"))
+
+	reader := bufio.NewReader(conn)
+
+	tests := []interface{}{
+		[]interface{}{field("host", "localhost"), field("x-Foo", "a"), field("X-SECRET", yare.DefaultRedactMask)},
+		[]interface{}{field("Host", "localhost"), field("Content-Length", "2"), field("x-bar", "c")},
+	}
+
+	for _, want := range tests {
+		resp, err := http.ReadResponse(reader, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		got, _ := yare.ParseJSON(data)
+		raw, _ := got["raw"].(map[string]interface{})
+
+		if raw["wire"] != true {
+			t.Errorf("EchoHandler() raw wire = %v, want true", raw["wire"])
+		}
+
+		if !reflect.DeepEqual(normalizeFields(raw["headers"]), want) {
+			t.Errorf("EchoHandler() raw headers = %v, want %v", raw["headers"], want)
+		}
+	}
+}
+
+// normalizeFields converts JSON decoded field objects to Dict.
+func normalizeFields(v interface{}) interface{} {
+	list, _ := v.([]interface{})
+	out := make([]interface{}, len(list))
+
+	for i, item := range list {
+		m, _ := item.(map[string]interface{})
+		out[i] = yare.Dict(m)
+	}
+
+	return out
+}
@@ -0,0 +1,512 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultRedactMask is the default replacement of redacted values.
+const DefaultRedactMask = "[REDACTED]"
+
+// Sections of the mapped Dict which can be redacted by key.
+const (
+	RedactHeaders       = "headers"
+	RedactCookies       = "cookies"
+	RedactQuery         = "query"
+	RedactForm          = "form"
+	RedactAuthorization = "authorization"
+	RedactClaims        = "claims"
+)
+
+var (
+	errRedactSection = errors.New("unknown redaction section")
+	errRedactRule    = errors.New("invalid redaction rule")
+	errRedactPath    = errors.New("invalid redaction path")
+)
+
+// Redactor replaces sensitive values of mapped Dict with a mask or with a salted hash.
+//
+// Key rules match the keys of a section: headers, cookies, query, form parameters, authorization schemes
+// or JWT claims (payload of parsed JWT authorization credentials and JWT body).
+// Form rules match multipart parts by field name too, replacing their content, parsed body and hash.
+// In the authorization section only unparsed credentials are replaced, parsed credentials
+// can be redacted by claims and path rules. Path rules select values by JSON path,
+// for example $.body.user.password or $.body.items[*].token.
+//
+// The zero value is an empty Redactor ready to use. Rules and fields must not be modified after first use.
+type Redactor struct {
+	// Mask replaces redacted values, empty means DefaultRedactMask.
+	Mask string
+
+	// Hash enables replacing redacted values with their HMAC-SHA256 hash keyed by Salt,
+	// so equal values can be correlated without revealing them.
+	Hash bool
+
+	// Salt is the HMAC key used in Hash mode.
+	Salt []byte
+
+	rules []redactRule
+}
+
+type redactRule struct {
+	section string
+	match   func(key string) bool
+	path    []string
+}
+
+// NewRedactor returns a new Redactor without rules.
+func NewRedactor() *Redactor {
+	return new(Redactor)
+}
+
+// DefaultRedactor returns a new Redactor with the default rules.
+func DefaultRedactor() *Redactor {
+	r := NewRedactor()
+	r.AddDefaultRules()
+
+	return r
+}
+
+// AddDefaultRules adds rules for commonly sensitive values: credential and cookie headers,
+// all cookies, token, secret and password like query and form parameters, unparsed authorization
+// credentials and Basic authentication password.
+func (r *Redactor) AddDefaultRules() {
+	defaults := map[string][]string{
+		RedactHeaders: {
+			"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie",
+			"X-Api-Key", "X-Auth-Token", "X-Csrf-Token",
+		},
+		RedactCookies:       {"*"},
+		RedactQuery:         {"api_key", "apikey", "*token*", "*secret*", "*password*", "signature"},
+		RedactForm:          {"*password*", "passwd", "*secret*", "*token*"},
+		RedactAuthorization: {"*"},
+	}
+
+	for _, section := range []string{RedactHeaders, RedactCookies, RedactQuery, RedactForm, RedactAuthorization} {
+		for _, pattern := range defaults[section] {
+			_ = r.AddKey(section, pattern)
+		}
+	}
+
+	_ = r.AddPath("$.authorization.Basic.password")
+}
+
+// AddKey adds a rule matching keys of a section by case insensitive glob pattern (see path.Match).
+func (r *Redactor) AddKey(section, pattern string) error {
+	if err := checkSection(section); err != nil {
+		return err
+	}
+
+	pattern = strings.ToLower(pattern)
+
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("%w: %s: %s", errRedactRule, pattern, err)
+	}
+
+	match := func(key string) bool {
+		ok, _ := path.Match(pattern, strings.ToLower(key))
+
+		return ok
+	}
+
+	r.rules = append(r.rules, redactRule{section: section, match: match})
+
+	return nil
+}
+
+// AddRegexp adds a rule matching keys of a section by regular expression.
+func (r *Redactor) AddRegexp(section string, re *regexp.Regexp) error {
+	if err := checkSection(section); err != nil {
+		return err
+	}
+
+	r.rules = append(r.rules, redactRule{section: section, match: re.MatchString})
+
+	return nil
+}
+
+// AddPath adds a rule matching values by JSON path.
+//
+// The path consists of dot separated keys and bracketed array indexes, optionally prefixed by $.
+// The * wildcard matches any key or index.
+func (r *Redactor) AddPath(jsonpath string) error {
+	p := strings.TrimPrefix(strings.TrimPrefix(jsonpath, "$"), ".")
+	p = strings.ReplaceAll(strings.ReplaceAll(p, "[", "."), "]", "")
+
+	if len(p) == 0 {
+		return fmt.Errorf("%w: %s", errRedactPath, jsonpath)
+	}
+
+	tokens := strings.Split(p, ".")
+
+	for _, token := range tokens {
+		if len(token) == 0 {
+			return fmt.Errorf("%w: %s", errRedactPath, jsonpath)
+		}
+	}
+
+	r.rules = append(r.rules, redactRule{path: tokens})
+
+	return nil
+}
+
+// AddRule adds a rule given in textual form.
+//
+// JSON path rules start with $ (like $.body.password), key rules have section:pattern form
+// (like query:*token*), where the pattern is a glob pattern or a regular expression between slashes
+// (like headers:/^x-secret-/).
+func (r *Redactor) AddRule(spec string) error {
+	if strings.HasPrefix(spec, "$") {
+		return r.AddPath(spec)
+	}
+
+	idx := strings.IndexByte(spec, ':')
+	if idx < 0 {
+		return fmt.Errorf("%w: %s", errRedactRule, spec)
+	}
+
+	section, pattern := spec[:idx], spec[idx+1:]
+
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return fmt.Errorf("%w: %s: %s", errRedactRule, spec, err)
+		}
+
+		return r.AddRegexp(section, re)
+	}
+
+	return r.AddKey(section, pattern)
+}
+
+// Redact replaces the values matching any rule in the mapped Dict.
+// It can be used as RedactFunc.
+//
+// The wire section is masked as a whole if there is any rule, because every redacted value
+// (header, cookie, query, form, claim or JSON path) may appear in the recorded bytes,
+// possibly encoded or compressed, so they can not be redacted selectively.
+func (r *Redactor) Redact(out Dict) {
+	if wire, ok := out["wire"].(Dict); ok && len(r.rules) != 0 && wire["redacted"] != true {
+		wire["data"] = r.replace(wire["data"])
+		wire["redacted"] = true
+	}
+
+	for _, rule := range r.rules {
+		if rule.path != nil {
+			r.redactPath(out, rule.path)
+
+			continue
+		}
+
+		switch rule.section {
+		case RedactHeaders:
+			if d, ok := out[rule.section].(Dict); ok {
+				r.redactKeys(d, rule.match, false)
+			}
+
+			r.redactRaw(out, rule.section, rule.match)
+		case RedactCookies:
+			if d, ok := out[rule.section].(Dict); ok {
+				r.redactCookies(d, rule.match)
+			}
+
+			r.redactRaw(out, rule.section, rule.match)
+		case RedactQuery:
+			if d, ok := out[rule.section].(Dict); ok {
+				r.redactKeys(d, rule.match, false)
+			}
+
+			if conn, ok := out["connection"].(Dict); ok {
+				if uri, ok := conn["request_uri"].(string); ok {
+					conn["request_uri"] = r.redactURI(uri, rule.match)
+				}
+			}
+		case RedactForm:
+			if d, ok := out[rule.section].(Dict); ok {
+				r.redactKeys(d, rule.match, false)
+			}
+
+			r.redactParts(out, rule.match)
+		case RedactClaims:
+			for _, claims := range jwtClaims(out) {
+				r.redactKeys(claims, rule.match, false)
+			}
+		case RedactAuthorization:
+			if d, ok := out[rule.section].(Dict); ok {
+				r.redactKeys(d, rule.match, true)
+			}
+		default:
+			if d, ok := out[rule.section].(Dict); ok {
+				r.redactKeys(d, rule.match, false)
+			}
+		}
+	}
+}
+
+// WithRedactor adds the Redactor to mapping as RedactFunc.
+func WithRedactor(r *Redactor) Option {
+	return WithRedact(r.Redact)
+}
+
+func checkSection(section string) error {
+	switch section {
+	case RedactHeaders, RedactCookies, RedactQuery, RedactForm, RedactAuthorization, RedactClaims:
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", errRedactSection, section)
+	}
+}
+
+// jwtClaims returns the payloads of parsed JWT authorization credentials and body.
+func jwtClaims(out Dict) []Dict {
+	candidates := []interface{}{out["body"]}
+
+	if d, ok := out[RedactAuthorization].(Dict); ok {
+		for _, v := range d {
+			candidates = append(candidates, v)
+		}
+	}
+
+	claims := []Dict{}
+
+	for _, v := range candidates {
+		d, ok := v.(Dict)
+		if !ok {
+			continue
+		}
+
+		if _, ok := d["verified"]; !ok {
+			continue
+		}
+
+		if payload, ok := d["payload"].(Dict); ok {
+			claims = append(claims, payload)
+		}
+	}
+
+	return claims
+}
+
+func (r *Redactor) redactKeys(d Dict, match func(string) bool, unparsed bool) {
+	for k, v := range d {
+		if !match(k) {
+			continue
+		}
+
+		if _, ok := v.(Dict); ok && unparsed {
+			continue
+		}
+
+		d[k] = r.replace(v)
+	}
+}
+
+// redactCookies replaces cookie values, response cookies are redacted without their attributes.
+func (r *Redactor) redactCookies(d Dict, match func(string) bool) {
+	for k, v := range d {
+		if !match(k) {
+			continue
+		}
+
+		switch val := v.(type) {
+		case Dict:
+			val["value"] = r.replace(val["value"])
+		case []interface{}:
+			for _, item := range val {
+				if cookie, ok := item.(Dict); ok {
+					cookie["value"] = r.replace(cookie["value"])
+				}
+			}
+		default:
+			d[k] = r.replace(v)
+		}
+	}
+}
+
+// redactRaw replaces the values of matching header fields or cookies in the raw section.
+func (r *Redactor) redactRaw(out Dict, section string, match func(string) bool) {
+	raw, ok := out["raw"].(Dict)
+	if !ok {
+		return
+	}
+
+	fields, _ := raw[section].([]interface{})
+
+	for _, item := range fields {
+		if field, ok := item.(Dict); ok {
+			if name, _ := field["name"].(string); match(name) {
+				field["value"] = r.replace(field["value"])
+			}
+		}
+	}
+}
+
+// redactParts replaces the content, parsed body and hash of multipart parts with matching field name.
+func (r *Redactor) redactParts(out Dict, match func(string) bool) {
+	parts, _ := out["multipart"].([]interface{})
+
+	for _, item := range parts {
+		part, ok := item.(Dict)
+		if !ok {
+			continue
+		}
+
+		if name, ok := part["name"].(string); !ok || !match(name) {
+			continue
+		}
+
+		for _, key := range []string{"content", "body", "sha256"} {
+			if v, ok := part[key]; ok {
+				part[key] = r.replace(v)
+			}
+		}
+	}
+}
+
+// redactURI replaces the matching query parameter values in the request URI, keeping the parameter order.
+func (r *Redactor) redactURI(uri string, match func(string) bool) string {
+	idx := strings.IndexByte(uri, '?')
+	if idx < 0 {
+		return uri
+	}
+
+	pairs := strings.Split(uri[idx+1:], "&")
+
+	for i, pair := range pairs {
+		key, value := pair, ""
+		if eq := strings.IndexByte(pair, '='); eq >= 0 {
+			key, value = pair[:eq], pair[eq+1:]
+		}
+
+		if name, err := url.QueryUnescape(key); err == nil && match(name) {
+			if unescaped, err := url.QueryUnescape(value); err == nil {
+				value = unescaped
+			}
+
+			pairs[i] = key + "=" + url.QueryEscape(r.mask(value))
+		}
+	}
+
+	return uri[:idx+1] + strings.Join(pairs, "&")
+}
+
+func (r *Redactor) redactPath(v interface{}, tokens []string) {
+	token, last := tokens[0], len(tokens) == 1
+
+	switch val := v.(type) {
+	case Dict:
+		for k, item := range val {
+			if token != "*" && token != k {
+				continue
+			}
+
+			if last {
+				val[k] = r.replace(item)
+			} else {
+				val[k] = ownStrings(item)
+				r.redactPath(val[k], tokens[1:])
+			}
+		}
+	case []interface{}:
+		for i, item := range val {
+			if token != "*" && token != strconv.Itoa(i) {
+				continue
+			}
+
+			if last {
+				val[i] = r.replace(item)
+			} else {
+				val[i] = ownStrings(item)
+				r.redactPath(val[i], tokens[1:])
+			}
+		}
+	case []string:
+		if !last {
+			return
+		}
+
+		for i, item := range val {
+			if token == "*" || token == strconv.Itoa(i) {
+				val[i] = r.mask(item)
+			}
+		}
+	}
+}
+
+// ownStrings copies string arrays, which may be shared with http.Header or url.Values.
+func ownStrings(v interface{}) interface{} {
+	if s, ok := v.([]string); ok {
+		return append([]string(nil), s...)
+	}
+
+	return v
+}
+
+// replace returns the redacted form of a value, array elements are redacted one by one.
+func (r *Redactor) replace(v interface{}) interface{} {
+	switch val := v.(type) {
+	case string:
+		return r.mask(val)
+	case []string:
+		out := make([]string, len(val))
+		for i, s := range val {
+			out[i] = r.mask(s)
+		}
+
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = r.replace(item)
+		}
+
+		return out
+	default:
+		return r.mask(fmt.Sprint(val))
+	}
+}
+
+func (r *Redactor) mask(value string) string {
+	if r.Hash {
+		mac := hmac.New(sha256.New, r.Salt)
+		_, _ = mac.Write([]byte(value))
+
+		return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	if len(r.Mask) != 0 {
+		return r.Mask
+	}
+
+	return DefaultRedactMask
+}
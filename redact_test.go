@@ -0,0 +1,208 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare_test
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/szkiba/yare"
+)
+
+func TestRedactor_Redact(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		rules []string
+		in    yare.Dict
+		want  yare.Dict
+	}{
+		{
+			name:  "headers",
+			rules: []string{"headers:x-secret-*"},
+			in:    yare.Dict{"headers": yare.Dict{"X-Secret-Key": "foo", "X-Other": "bar"}},
+			want:  yare.Dict{"headers": yare.Dict{"X-Secret-Key": "[REDACTED]", "X-Other": "bar"}},
+		},
+		{
+			name:  "multi values",
+			rules: []string{"query:token"},
+			in:    yare.Dict{"query": yare.Dict{"token": []string{"foo", "bar"}}},
+			want:  yare.Dict{"query": yare.Dict{"token": []string{"[REDACTED]", "[REDACTED]"}}},
+		},
+		{
+			name:  "regexp",
+			rules: []string{"form:/^pass/"},
+			in:    yare.Dict{"form": yare.Dict{"password": "foo", "user": "bar"}},
+			want:  yare.Dict{"form": yare.Dict{"password": "[REDACTED]", "user": "bar"}},
+		},
+		{
+			name:  "multipart",
+			rules: []string{"form:*password*"},
+			in: yare.Dict{"multipart": []interface{}{
+				yare.Dict{"name": "password", "size": 3, "sha256": "2c26b4", "content": "foo", "body": yare.Dict{"a": "b"}},
+				yare.Dict{"name": "user", "size": 3, "sha256": "fcde2b", "content": "bar"},
+				yare.Dict{"filename": "password.txt", "size": 3, "sha256": "baa5a0", "content": "baz"},
+			}},
+			want: yare.Dict{"multipart": []interface{}{
+				yare.Dict{
+					"name": "password", "size": 3, "sha256": "[REDACTED]", "content": "[REDACTED]", "body": "[REDACTED]",
+				},
+				yare.Dict{"name": "user", "size": 3, "sha256": "fcde2b", "content": "bar"},
+				yare.Dict{"filename": "password.txt", "size": 3, "sha256": "baa5a0", "content": "baz"},
+			}},
+		},
+		{
+			name:  "claims",
+			rules: []string{"claims:email"},
+			in: yare.Dict{"authorization": yare.Dict{
+				"Bearer": yare.Dict{"payload": yare.Dict{"email": "foo@example.com", "sub": "foo"}, "verified": false},
+			}},
+			want: yare.Dict{"authorization": yare.Dict{
+				"Bearer": yare.Dict{"payload": yare.Dict{"email": "[REDACTED]", "sub": "foo"}, "verified": false},
+			}},
+		},
+		{
+			name:  "unparsed authorization",
+			rules: []string{"authorization:*"},
+			in:    yare.Dict{"authorization": yare.Dict{"Bearer": "foo", "Basic": yare.Dict{"username": "foo"}}},
+			want:  yare.Dict{"authorization": yare.Dict{"Bearer": "[REDACTED]", "Basic": yare.Dict{"username": "foo"}}},
+		},
+		{
+			name:  "path",
+			rules: []string{"$.body.items[*].secret", "$.body.list[1]"},
+			in: yare.Dict{"body": yare.Dict{
+				"items": []interface{}{yare.Dict{"secret": "foo", "id": "1"}, yare.Dict{"secret": "bar"}},
+				"list":  []interface{}{"a", "b", "c"},
+			}},
+			want: yare.Dict{"body": yare.Dict{
+				"items": []interface{}{yare.Dict{"secret": "[REDACTED]", "id": "1"}, yare.Dict{"secret": "[REDACTED]"}},
+				"list":  []interface{}{"a", "[REDACTED]", "c"},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := yare.NewRedactor()
+			for _, rule := range tt.rules {
+				if err := r.AddRule(rule); err != nil {
+					t.Errorf("Redactor.AddRule() error = %v", err)
+				}
+			}
+
+			r.Redact(tt.in)
+
+			if !reflect.DeepEqual(tt.in, tt.want) {
+				t.Errorf("Redactor.Redact() = %v, want %v", tt.in, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactor_AddRuleError(t *testing.T) {
+	t.Parallel()
+
+	r := yare.NewRedactor()
+
+	for _, rule := range []string{"dummy", "dummy:foo", "query:[", "query:/(/", "$", "$.foo..bar"} {
+		if err := r.AddRule(rule); err == nil {
+			t.Errorf("Redactor.AddRule(%q) error is nil", rule)
+		}
+	}
+
+	if err := r.AddRegexp("dummy", regexp.MustCompile("foo")); err == nil {
+		t.Error("Redactor.AddRegexp() error is nil")
+	}
+}
+
+func TestRedactor_Hash(t *testing.T) {
+	t.Parallel()
+
+	r := yare.NewRedactor()
+	r.Hash = true
+	r.Salt = []byte("salt")
+	_ = r.AddKey(yare.RedactCookies, "*")
+
+	out := yare.Dict{"cookies": yare.Dict{"a": "foo", "b": "foo", "c": "bar"}}
+	r.Redact(out)
+
+	cookies, _ := out["cookies"].(yare.Dict)
+
+	if s, _ := cookies["a"].(string); !strings.HasPrefix(s, "hmac-sha256:") || len(s) != 12+64 {
+		t.Errorf("Redactor.Redact() hash = %v", cookies["a"])
+	}
+
+	if cookies["a"] != cookies["b"] || cookies["a"] == cookies["c"] {
+		t.Errorf("Redactor.Redact() hashes = %v", cookies)
+	}
+}
+
+func TestWithRedactor(t *testing.T) {
+	t.Parallel()
+
+	header := kv{
+		"Authorization": "Basic Zm9vOmJhcg==",
+		"Cookie":        "session=secret",
+		"Content-Type":  "application/x-www-form-urlencoded",
+	}
+
+	r := newRequest(par{
+		method: http.MethodPost, url: "http://localhost/?api_key=secret&page=1",
+		body: "password=secret&user=foo", header: header,
+	})
+
+	m := yare.NewMapper()
+	m.RegisterAuthScheme("Basic", yare.ParseBasic)
+
+	got, err := m.MapRequestWithOptions(r, yare.WithConnection(true), yare.WithRedactor(yare.DefaultRedactor()))
+	if err != nil {
+		t.Errorf("Mapper.MapRequestWithOptions() error = %v", err)
+	}
+
+	want := yare.Dict{
+		"version": "HTTP/1.1", "method": http.MethodPost, "path": "/",
+		"headers": yare.Dict{
+			"Authorization": "[REDACTED]", "Cookie": "[REDACTED]", "Content-Type": "application/x-www-form-urlencoded",
+		},
+		"cookies":       yare.Dict{"session": "[REDACTED]"},
+		"query":         yare.Dict{"api_key": "[REDACTED]", "page": "1"},
+		"form":          yare.Dict{"password": "[REDACTED]", "user": "foo"},
+		"authorization": yare.Dict{"Basic": yare.Dict{"username": "foo", "password": "[REDACTED]"}},
+		"connection": yare.Dict{
+			"remote": "192.0.2.1:1234", "host": "localhost", "request_uri": "http://localhost/?api_key=%5BREDACTED%5D&page=1",
+			"content_length": int64(24), "protocol": yare.Dict{"name": yare.ProtocolHTTP11},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Mapper.MapRequestWithOptions() = %v, want %v", got, want)
+	}
+
+	if v := r.Header.Get("Authorization"); v != "Basic Zm9vOmJhcg==" {
+		t.Errorf("request header modified: %v", v)
+	}
+}
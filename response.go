@@ -0,0 +1,182 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+)
+
+// responseRecorder records the status code and the size of the response written through it.
+// The writer returned by writer passes Flush and Hijack to the underlying writer if it supports them,
+// so streaming and upgraded connections keep working.
+//
+// If body is not nil, the header sent and at most max bytes of the body are captured too.
+// The captured header gets the Content-Type detected from the first write if the handler did not set it,
+// like net/http does.
+type responseRecorder struct {
+	http.ResponseWriter
+	status   int
+	size     int64
+	hijacked bool
+
+	header  http.Header
+	body    *bytes.Buffer
+	max     int64
+	sniffed bool
+}
+
+// writer returns the recorder as http.ResponseWriter implementing http.Flusher and http.Hijacker
+// only if the underlying writer does.
+func (w *responseRecorder) writer() http.ResponseWriter {
+	_, flusher := w.ResponseWriter.(http.Flusher)
+	_, hijacker := w.ResponseWriter.(http.Hijacker)
+
+	switch {
+	case flusher && hijacker:
+		return flushHijackRecorder{w}
+	case flusher:
+		return flushRecorder{w}
+	case hijacker:
+		return hijackRecorder{w}
+	default:
+		return w
+	}
+}
+
+type flushRecorder struct{ *responseRecorder }
+
+func (w flushRecorder) Flush() { w.flush() }
+
+type hijackRecorder struct{ *responseRecorder }
+
+func (w hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) { return w.hijack() }
+
+type flushHijackRecorder struct{ *responseRecorder }
+
+func (w flushHijackRecorder) Flush() { w.flush() }
+
+func (w flushHijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) { return w.hijack() }
+
+func (w *responseRecorder) WriteHeader(code int) {
+	// informational responses (except protocol switch) are followed by the final one
+	if w.status == 0 && (code >= http.StatusOK || code == http.StatusSwitchingProtocols) {
+		w.setStatus(code)
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseRecorder) setStatus(code int) {
+	w.status = code
+
+	if w.body != nil {
+		w.header = w.Header().Clone()
+	}
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.setStatus(http.StatusOK)
+	}
+
+	w.sniff(data)
+
+	n, err := w.ResponseWriter.Write(data)
+	w.size += int64(n)
+
+	if w.body != nil {
+		if room := w.max - int64(w.body.Len()); room > 0 {
+			chunk := data[:n]
+			if int64(len(chunk)) > room {
+				chunk = chunk[:room]
+			}
+
+			w.body.Write(chunk)
+		}
+	}
+
+	return n, err
+}
+
+// sniff adds the Content-Type detected from the first written data to the captured header
+// if it has no Content-Type, Content-Encoding or Transfer-Encoding and the status allows a body.
+func (w *responseRecorder) sniff(data []byte) {
+	if w.header == nil || w.sniffed || len(data) == 0 {
+		return
+	}
+
+	w.sniffed = true
+
+	if _, ok := w.header["Content-Type"]; ok || !bodyAllowed(w.status) {
+		return
+	}
+
+	if len(w.header.Get("Content-Encoding")) != 0 || len(w.header.Get("Transfer-Encoding")) != 0 {
+		return
+	}
+
+	w.header.Set("Content-Type", http.DetectContentType(data))
+}
+
+func bodyAllowed(status int) bool {
+	return status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// flush must be called only if the underlying writer is http.Flusher.
+func (w *responseRecorder) flush() {
+	if w.status == 0 {
+		w.setStatus(http.StatusOK)
+	}
+
+	// the header is sent without the body
+	w.sniffed = true
+
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+// hijack must be called only if the underlying writer is http.Hijacker.
+func (w *responseRecorder) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := w.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+
+	return conn, rw, err
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (w *responseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// statusCode returns the status sent to the client, http.StatusOK if the handler wrote nothing.
+func (w *responseRecorder) statusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+
+	return w.status
+}
@@ -0,0 +1,155 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_responseRecorder(t *testing.T) {
+	t.Parallel()
+
+	base := httptest.NewRecorder()
+	rec := &responseRecorder{ResponseWriter: base}
+	w := rec.writer()
+
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = w.Write([]byte("foo"))
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		t.Fatal("writer() is not http.Flusher")
+	}
+
+	flusher.Flush()
+
+	if rec.statusCode() != http.StatusAccepted || rec.size != 3 {
+		t.Errorf("status, size = %d, %d, want %d, 3", rec.statusCode(), rec.size, http.StatusAccepted)
+	}
+
+	if !base.Flushed {
+		t.Error("Flush() not passed to the underlying writer")
+	}
+
+	if http.NewResponseController(w).Flush() != nil {
+		t.Error("ResponseController.Flush() failed")
+	}
+
+	if _, ok := w.(http.Hijacker); ok {
+		t.Error("writer() is http.Hijacker, underlying writer is not")
+	}
+
+	if (&responseRecorder{}).statusCode() != http.StatusOK {
+		t.Error("statusCode() of empty response, want 200")
+	}
+
+	informational := &responseRecorder{ResponseWriter: httptest.NewRecorder()}
+	informational.WriteHeader(http.StatusEarlyHints)
+
+	if informational.status != 0 {
+		t.Errorf("status = %d after informational response, want 0", informational.status)
+	}
+}
+
+type plainWriter struct{ http.ResponseWriter }
+
+type hijackWriter struct{ http.ResponseWriter }
+
+func (hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) { return nil, nil, nil }
+
+func Test_responseRecorderWriter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		w        http.ResponseWriter
+		flusher  bool
+		hijacker bool
+	}{
+		{name: "plain", w: plainWriter{httptest.NewRecorder()}},
+		{name: "flusher", w: httptest.NewRecorder(), flusher: true},
+		{name: "hijacker", w: hijackWriter{httptest.NewRecorder()}, hijacker: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := (&responseRecorder{ResponseWriter: tt.w}).writer()
+
+			if _, ok := w.(http.Flusher); ok != tt.flusher {
+				t.Errorf("writer() is http.Flusher = %v, want %v", ok, tt.flusher)
+			}
+
+			if _, ok := w.(http.Hijacker); ok != tt.hijacker {
+				t.Errorf("writer() is http.Hijacker = %v, want %v", ok, tt.hijacker)
+			}
+		})
+	}
+}
+
+func Test_responseRecorderHijack(t *testing.T) {
+	t.Parallel()
+
+	hijacked := make(chan bool, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &responseRecorder{ResponseWriter: w}
+
+		hijacker, ok := rec.writer().(http.Hijacker)
+		if !ok {
+			hijacked <- false
+
+			return
+		}
+
+		conn, rw, err := hijacker.Hijack()
+		if err != nil {
+			hijacked <- false
+
+			return
+		}
+
+		defer conn.Close()
+
+		_, _ = rw.WriteString("HTTP/1.1 204 No Content\r\n\r\n")
+		_ = rw.Flush()
+
+		hijacked <- rec.hijacked
+	}))
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp.Body.Close()
+
+	if !<-hijacked || resp.StatusCode != http.StatusNoContent {
+		t.Errorf("hijack failed, status = %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,87 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare
+
+import (
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Formats of TOML local date-time, local date and local time values by the names of their locations,
+// the locations themselves are internal to the toml package.
+var tomlLocalFormats = map[string]string{
+	"datetime-local": "2006-01-02T15:04:05.999999999",
+	"date-local":     "2006-01-02",
+	"time-local":     "15:04:05.999999999",
+}
+
+// ParseTOML is a ParserFunc for parsing TOML document to Dict.
+//
+// Can use as RegisterContentType parser argument.
+func ParseTOML(in []byte) (Dict, error) {
+	out := make(Dict)
+
+	if _, err := toml.Decode(string(in), &out); err != nil {
+		return nil, wrapError(err)
+	}
+
+	for k, v := range out {
+		out[k] = normalizeTOML(v)
+	}
+
+	return out, nil
+}
+
+// normalizeTOML converts local date-time, local date and local time values to their TOML string form,
+// which would be marshaled as UTC timestamps otherwise.
+func normalizeTOML(v interface{}) interface{} {
+	switch t := v.(type) {
+	case time.Time:
+		if format, ok := tomlLocalFormats[t.Location().String()]; ok {
+			return t.Format(format)
+		}
+
+		return t
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = normalizeTOML(e)
+		}
+
+		return t
+	case []map[string]interface{}:
+		for _, e := range t {
+			normalizeTOML(e)
+		}
+
+		return t
+	case []interface{}:
+		for i, e := range t {
+			t[i] = normalizeTOML(e)
+		}
+
+		return t
+	default:
+		return v
+	}
+}
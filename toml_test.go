@@ -0,0 +1,100 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/szkiba/yare"
+)
+
+func TestParseTOML(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		in string
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		want    yare.Dict
+		wantErr bool
+	}{
+		{
+			name: "normal",
+			args: args{in: "foo = \"bar\"\ncount = 2\n\n[server]\nports = [80, 443]\n\n[[user]]\nname = \"joe\"\n"},
+			want: yare.Dict{
+				"foo":    "bar",
+				"count":  int64(2),
+				"server": map[string]interface{}{"ports": []interface{}{int64(80), int64(443)}},
+				"user":   []map[string]interface{}{{"name": "joe"}},
+			},
+		},
+		{
+			name: "local",
+			args: args{
+				in: "dt = 1979-05-27T07:32:00.5\nd = 1979-05-27\n\n[server]\nt = [07:32:00]\n\n[[user]]\nd = 2000-01-01\n",
+			},
+			want: yare.Dict{
+				"dt":     "1979-05-27T07:32:00.5",
+				"d":      "1979-05-27",
+				"server": map[string]interface{}{"t": []interface{}{"07:32:00"}},
+				"user":   []map[string]interface{}{{"d": "2000-01-01"}},
+			},
+		},
+		{
+			name: "offset",
+			args: args{in: "dt = 1979-05-27T07:32:00Z\n"},
+			want: yare.Dict{"dt": time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC)},
+		},
+		{
+			name: "empty",
+			args: args{in: ""},
+			want: yare.Dict{},
+		},
+		{
+			name:    "invalid",
+			args:    args{in: "foo = "},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := yare.ParseTOML([]byte(tt.args.in))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseTOML() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTOML() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
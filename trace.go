@@ -0,0 +1,133 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+)
+
+// DefaultTraceMaxBody is the maximum number of response body bytes captured by TraceMiddleware
+// if no body size limit is set.
+const DefaultTraceMaxBody = 64 * 1024
+
+// TraceSink receives the mapped request and response of an exchange handled by TraceMiddleware.
+// It is called after the wrapped handler returned, concurrently for concurrent requests.
+type TraceSink func(request, response Dict)
+
+// TraceMiddleware returns a handler which serves requests with next and passes the mapped request
+// and response to sink using DefaultMapper.
+func TraceMiddleware(next http.Handler, sink TraceSink, opts ...Option) http.Handler {
+	return DefaultMapper.TraceMiddleware(next, sink, opts...)
+}
+
+// TraceMiddleware returns a handler which serves requests with next and passes the mapped request
+// and response to sink.
+//
+// Both sides are mapped with the given options, like MapRequestWithOptions and MapResponseWithOptions.
+// The request is mapped before calling next, so a mapped body remains readable by next.
+// The response status, header and (with WithBody) at most the body size limit
+// (DefaultTraceMaxBody if no limit is set) bytes of the body are captured while passed to the client.
+// A truncated body is reported in the capture section without decoding its content encoding
+// or parsing it, because a prefix of an encoded body can not be decoded.
+// The writer passed to next supports http.Flusher and http.Hijacker if the original writer does.
+// Mapping errors are reported in the errors field of the Dicts.
+// An echo handler of the same Mapper created with the same option slice reuses the request mapping.
+// A nil sink disables tracing, next is returned as is.
+func (m *Mapper) TraceMiddleware(next http.Handler, sink TraceSink, opts ...Option) http.Handler {
+	if sink == nil {
+		return next
+	}
+
+	return &tracer{mapper: m, next: next, sink: sink, opts: opts}
+}
+
+type tracer struct {
+	mapper *Mapper
+	next   http.Handler
+	sink   TraceSink
+	opts   []Option
+}
+
+func (t *tracer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	request, err := t.mapper.MapRequestWithOptions(r, t.opts...)
+	r = shareMapping(r, t.mapper, t.opts, request, err)
+	request = withErrors(request, err)
+
+	rec := &responseRecorder{ResponseWriter: w}
+	opts := t.opts
+
+	if o := t.mapper.newOptions(opts); o.body {
+		max := o.maxBodySize
+		if max <= 0 {
+			max = DefaultTraceMaxBody
+			opts = append(opts[:len(opts):len(opts)], WithMaxBodySize(max))
+		}
+
+		// one more byte to detect truncation
+		rec.body, rec.max = new(bytes.Buffer), max+1
+	}
+
+	t.next.ServeHTTP(rec.writer(), r)
+
+	resp := &http.Response{
+		StatusCode:    rec.statusCode(),
+		Proto:         r.Proto,
+		ProtoMajor:    r.ProtoMajor,
+		ProtoMinor:    r.ProtoMinor,
+		Header:        rec.header,
+		ContentLength: rec.size,
+		Request:       r,
+	}
+
+	if resp.Header == nil {
+		resp.Header = w.Header().Clone()
+	}
+
+	if rec.body != nil {
+		resp.Body = ioutil.NopCloser(rec.body)
+	}
+
+	response, err := t.mapper.MapResponseWithOptions(resp, opts...)
+	response = withErrors(response, err)
+
+	if rec.hijacked {
+		response["hijacked"] = true
+	}
+
+	t.sink(request, response)
+}
+
+// withErrors adds the errors field to the mapped output, creating the output if needed.
+func withErrors(out Dict, err error) Dict {
+	if out == nil {
+		out = make(Dict)
+	}
+
+	if err != nil {
+		out["errors"] = errorDicts(err)
+	}
+
+	return out
+}
@@ -0,0 +1,298 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/szkiba/yare"
+)
+
+type traces struct {
+	mu    sync.Mutex
+	pairs [][2]yare.Dict
+}
+
+func (t *traces) sink(request, response yare.Dict) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pairs = append(t.pairs, [2]yare.Dict{request, response})
+}
+
+func (t *traces) last() (yare.Dict, yare.Dict) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.pairs) == 0 {
+		return nil, nil
+	}
+
+	pair := t.pairs[len(t.pairs)-1]
+
+	return pair[0], pair[1]
+}
+
+func TestTraceMiddleware(t *testing.T) {
+	t.Parallel()
+
+	tr := new(traces)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		w.Header().Set("Content-Type", "application/json")
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "42", HttpOnly: true})
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"echo":`))
+		_, _ = w.Write(body)
+		_, _ = w.Write([]byte(`}`))
+
+		w.Header().Set("X-Late", "ignored")
+	})
+
+	h := yare.NewMapper().TraceMiddleware(next, tr.sink,
+		yare.WithBody(true), yare.WithContentType("application/json", yare.ParseJSON))
+
+	req := httptest.NewRequest(http.MethodPost, "http://localhost/path", strings.NewReader(`{"foo":"bar"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(rec, req)
+
+	if want := `{"echo":{"foo":"bar"}}`; rec.Body.String() != want || rec.Code != http.StatusCreated {
+		t.Errorf("response = %d %s, want %d %s", rec.Code, rec.Body.String(), http.StatusCreated, want)
+	}
+
+	request, response := tr.last()
+
+	if request["method"] != http.MethodPost || !reflect.DeepEqual(request["body"], yare.Dict{"foo": "bar"}) {
+		t.Errorf("request = %v, want POST with body", request)
+	}
+
+	want := yare.Dict{
+		"version": "HTTP/1.1",
+		"status":  http.StatusCreated,
+		"headers": yare.Dict{"Content-Type": "application/json", "Set-Cookie": "session=42; HttpOnly"},
+		"cookies": yare.Dict{"session": yare.Dict{"value": "42", "http_only": true}},
+		"body":    yare.Dict{"echo": map[string]interface{}{"foo": "bar"}},
+	}
+
+	if !reflect.DeepEqual(response, want) {
+		t.Errorf("response = %v, want %v", response, want)
+	}
+}
+
+func TestTraceMiddlewareTruncated(t *testing.T) {
+	t.Parallel()
+
+	tr := new(traces)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello "))
+		_, _ = w.Write([]byte("world"))
+	})
+
+	h := yare.TraceMiddleware(next, tr.sink, yare.WithBody(true), yare.WithMaxBodySize(4))
+
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Body.String() != "hello world" {
+		t.Errorf("response body = %s, want hello world", rec.Body.String())
+	}
+
+	_, response := tr.last()
+
+	want := yare.Dict{"truncated": true, "captured": 4, "size": int64(11)}
+	if !reflect.DeepEqual(response["capture"], want) {
+		t.Errorf("capture = %v, want %v", response["capture"], want)
+	}
+
+	h = yare.TraceMiddleware(next, tr.sink)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if _, response = tr.last(); response["body"] != nil || response["capture"] != nil {
+		t.Errorf("response = %v, want no body", response)
+	}
+}
+
+func TestTraceMiddlewareTruncatedEncoded(t *testing.T) {
+	t.Parallel()
+
+	var compressed bytes.Buffer
+
+	zw := gzip.NewWriter(&compressed)
+	_, _ = zw.Write([]byte(`{"message":"hello world"}`))
+	_ = zw.Close()
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write(compressed.Bytes())
+	})
+
+	tr := new(traces)
+
+	h := yare.TraceMiddleware(next, tr.sink, yare.WithBody(true), yare.WithMaxBodySize(8))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	_, response := tr.last()
+
+	if response["errors"] != nil || response["encoding"] != nil {
+		t.Errorf("response = %v, want truncated body without decoding", response)
+	}
+
+	want := yare.Dict{"truncated": true, "captured": 8, "size": int64(compressed.Len())}
+	if !reflect.DeepEqual(response["capture"], want) {
+		t.Errorf("capture = %v, want %v", response["capture"], want)
+	}
+}
+
+func TestTraceMiddlewareSniff(t *testing.T) {
+	t.Parallel()
+
+	tr := new(traces)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html><body>hello</body></html>"))
+	})
+
+	srv := httptest.NewServer(yare.TraceMiddleware(next, tr.sink, yare.WithBody(true)))
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp.Body.Close()
+
+	_, response := tr.last()
+
+	if response["errors"] != nil {
+		t.Errorf("response errors = %v, want none", response["errors"])
+	}
+
+	headers, _ := response["headers"].(yare.Dict)
+
+	if want := resp.Header.Get("Content-Type"); headers["Content-Type"] != want {
+		t.Errorf("response Content-Type = %v, want %s", headers["Content-Type"], want)
+	}
+}
+
+func TestTraceMiddlewareNilSink(t *testing.T) {
+	t.Parallel()
+
+	next := http.NotFoundHandler()
+
+	h := yare.TraceMiddleware(next, nil)
+
+	if reflect.ValueOf(h).Pointer() != reflect.ValueOf(next).Pointer() {
+		t.Error("TraceMiddleware() with nil sink, want next")
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestTraceMiddlewareHijack(t *testing.T) {
+	t.Parallel()
+
+	tr := new(traces)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(http.Flusher); !ok {
+			http.Error(w, "not flusher", http.StatusInternalServerError)
+
+			return
+		}
+
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		defer conn.Close()
+
+		_, _ = rw.WriteString("HTTP/1.1 204 No Content\r\n\r\n")
+		_ = rw.Flush()
+	})
+
+	srv := httptest.NewServer(yare.TraceMiddleware(next, tr.sink))
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+
+	srv.Close()
+
+	if _, response := tr.last(); response["hijacked"] != true {
+		t.Errorf("response = %v, want hijacked", response)
+	}
+}
+
+func TestTraceMiddlewareError(t *testing.T) {
+	t.Parallel()
+
+	tr := new(traces)
+
+	h := yare.TraceMiddleware(http.NotFoundHandler(), tr.sink, yare.WithContentType("dummy/", yare.ParseJSON))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	request, response := tr.last()
+
+	if request["errors"] == nil || response["errors"] == nil {
+		t.Errorf("request, response = %v, %v, want errors", request, response)
+	}
+}
@@ -0,0 +1,353 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultWireMax is the default maximum number of bytes kept per connection by WireListener.
+const DefaultWireMax = 1024 * 1024
+
+// Formats of the wire section.
+const (
+	// WireRaw is the recorded request as string (invalid UTF-8 sequences are replaced in JSON output).
+	WireRaw = "raw"
+	// WireHex is the hex dump (like hexdump -C) of the recorded request.
+	WireHex = "hex"
+	// WireEscaped is the recorded request with Go escape sequences, one line per request line.
+	WireEscaped = "escaped"
+)
+
+// ErrWireFormat is returned (wrapped) for a wire format other than WireRaw, WireHex or WireEscaped.
+var ErrWireFormat = errors.New("wire format must be raw, hex or escaped")
+
+// WireListener returns a net.Listener which records the bytes read from accepted connections,
+// so the mapping can report the request as seen on the wire (original header order and casing).
+//
+// At most max bytes are kept per connection (zero or negative means DefaultWireMax).
+// The http.Server using the listener must set WireContext as ConnContext.
+func WireListener(l net.Listener, max int) net.Listener {
+	if max <= 0 {
+		max = DefaultWireMax
+	}
+
+	return &wireListener{Listener: l, max: max}
+}
+
+// WireContext stores the wire recorder of a connection accepted by WireListener in the context.
+// It can be used as http.Server ConnContext.
+func WireContext(ctx context.Context, c net.Conn) context.Context {
+	if wc, ok := c.(*wireConn); ok {
+		return context.WithValue(ctx, wireContextKey{}, wc.rec)
+	}
+
+	return ctx
+}
+
+type wireContextKey struct{}
+
+type wireListener struct {
+	net.Listener
+	max int
+}
+
+func (l *wireListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	return &wireConn{Conn: c, rec: &wireRecorder{max: l.max}}, nil
+}
+
+type wireConn struct {
+	net.Conn
+	rec *wireRecorder
+}
+
+func (c *wireConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		c.rec.write(p[:n])
+	}
+
+	return n, err
+}
+
+// wireRecorder keeps the recent bytes read from a connection.
+type wireRecorder struct {
+	mu   sync.Mutex
+	buf  []byte
+	max  int
+	last *wireFrame
+	head http.Header
+}
+
+// wireFrame is the body framing of a request, used to find where the request ends in the recorded bytes.
+type wireFrame struct {
+	chunked bool
+	length  int64
+}
+
+func newWireFrame(r *http.Request) *wireFrame {
+	return &wireFrame{
+		chunked: len(r.TransferEncoding) != 0 && r.TransferEncoding[0] == "chunked",
+		length:  r.ContentLength,
+	}
+}
+
+func (w *wireRecorder) write(p []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+
+	// trimming only when twice the limit reached keeps copying amortized
+	if len(w.buf) > 2*w.max {
+		w.buf = append([]byte(nil), w.buf[len(w.buf)-w.max:]...)
+		w.last, w.head = nil, nil
+	}
+}
+
+// request returns the recorded bytes starting with the request line of r,
+// bytes recorded before the request line are dropped.
+//
+// For a request other than the previously returned one (told by the identity of the header map,
+// which is shared by shallow copies like http.Request.WithContext) the previous request is dropped too,
+// so a keep-alive connection repeating the same request line does not return the first request again.
+// The previous header map is referenced, so its address can not be reused by the next request.
+func (w *wireRecorder) request(r *http.Request) []byte {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.last != nil && reflect.ValueOf(w.head).Pointer() != reflect.ValueOf(r.Header).Pointer() {
+		if end, complete := frameEnd(w.buf, w.last); complete {
+			w.buf = w.buf[end:]
+		}
+	}
+
+	idx := bytes.Index(w.buf, []byte(r.Method+" "+r.RequestURI+" "+r.Proto))
+	if idx < 0 {
+		return nil
+	}
+
+	w.buf = w.buf[idx:]
+	w.last, w.head = newWireFrame(r), r.Header
+
+	return append([]byte(nil), w.buf...)
+}
+
+// wireRequest returns the recorded bytes of the request, nil if not recorded.
+func wireRequest(r *http.Request) []byte {
+	rec, ok := r.Context().Value(wireContextKey{}).(*wireRecorder)
+	if !ok {
+		return nil
+	}
+
+	return rec.request(r)
+}
+
+// mapWire creates the wire section of a request recorded by WireListener, nil if not recorded.
+//
+// The section contains the bytes read until the mapping, the complete flag tells whether
+// the whole request (body and trailers included) was read.
+func mapWire(r *http.Request, format string) Dict {
+	data := wireRequest(r)
+	if data == nil {
+		return nil
+	}
+
+	end, complete := requestEnd(data, r)
+	data = data[:end]
+
+	out := Dict{"format": format, "size": len(data), "complete": complete}
+
+	switch format {
+	case WireHex:
+		out["data"] = hex.Dump(data)
+	case WireEscaped:
+		out["data"] = escapeWire(data)
+	default:
+		out["data"] = string(data)
+	}
+
+	return out
+}
+
+func escapeWire(data []byte) string {
+	var sb strings.Builder
+
+	for len(data) != 0 {
+		line := data
+
+		if idx := bytes.IndexByte(data, '\n'); idx >= 0 {
+			line = data[:idx+1]
+		}
+
+		data = data[len(line):]
+
+		quoted := strconv.Quote(string(line))
+		sb.WriteString(quoted[1 : len(quoted)-1])
+		sb.WriteRune('\n')
+	}
+
+	return sb.String()
+}
+
+// headEnd returns the end of the header lines and the start of the body in the recorded request.
+func headEnd(data []byte) (int, int) {
+	end, body := bytes.Index(data, []byte("\n\r\n")), 3
+
+	if alt := bytes.Index(data, []byte("\n\n")); alt >= 0 && (end < 0 || alt < end) {
+		end, body = alt, 2
+	}
+
+	if end < 0 {
+		return -1, -1
+	}
+
+	return end, end + body
+}
+
+// wireHead returns the header lines of the recorded request, the request line excluded.
+func wireHead(data []byte) ([]string, bool) {
+	end, _ := headEnd(data)
+	if end < 0 {
+		return nil, false
+	}
+
+	lines := []string{}
+
+	for i, line := range bytes.Split(data[:end], []byte("\n")) {
+		if i == 0 {
+			continue
+		}
+
+		line = bytes.TrimSuffix(line, []byte("\r"))
+
+		// obsolete line folding continues the previous line
+		if n := len(lines); n > 0 && len(line) > 0 && (line[0] == ' ' || line[0] == '\t') {
+			lines[n-1] += " " + string(bytes.TrimSpace(line))
+
+			continue
+		}
+
+		lines = append(lines, string(line))
+	}
+
+	return lines, true
+}
+
+// requestEnd returns the end of the recorded request, using Content-Length or chunked framing.
+// The returned flag is false if the recorded data does not contain the complete request.
+func requestEnd(data []byte, r *http.Request) (int, bool) {
+	return frameEnd(data, newWireFrame(r))
+}
+
+// frameEnd returns the end of the recorded request with the given body framing.
+func frameEnd(data []byte, frame *wireFrame) (int, bool) {
+	_, body := headEnd(data)
+	if body < 0 {
+		return len(data), false
+	}
+
+	if frame.chunked {
+		return chunkedEnd(data, body)
+	}
+
+	end := body
+	if frame.length > 0 {
+		end += int(frame.length)
+	}
+
+	if end > len(data) {
+		return len(data), false
+	}
+
+	return end, true
+}
+
+// chunkedEnd returns the end of chunked body (trailers included) starting at pos.
+func chunkedEnd(data []byte, pos int) (int, bool) {
+	for {
+		idx := bytes.IndexByte(data[pos:], '\n')
+		if idx < 0 {
+			return len(data), false
+		}
+
+		line := string(bytes.TrimSpace(data[pos : pos+idx]))
+		pos += idx + 1
+
+		if semi := strings.IndexByte(line, ';'); semi >= 0 {
+			line = line[:semi]
+		}
+
+		size, err := strconv.ParseInt(strings.TrimSpace(line), 16, 64)
+		if err != nil || size < 0 {
+			return len(data), false
+		}
+
+		if size == 0 {
+			break
+		}
+
+		if int64(len(data)-pos) < size {
+			return len(data), false
+		}
+
+		pos += int(size)
+
+		// CRLF after chunk data
+		idx = bytes.IndexByte(data[pos:], '\n')
+		if idx < 0 {
+			return len(data), false
+		}
+
+		pos += idx + 1
+	}
+
+	// trailer fields until empty line
+	for {
+		idx := bytes.IndexByte(data[pos:], '\n')
+		if idx < 0 {
+			return len(data), false
+		}
+
+		empty := len(bytes.TrimSpace(data[pos:pos+idx])) == 0
+		pos += idx + 1
+
+		if empty {
+			return pos, true
+		}
+	}
+}
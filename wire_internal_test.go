@@ -0,0 +1,92 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare
+
+import (
+	"bufio"
+	"net/http"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func Test_requestEnd(t *testing.T) {
+	t.Parallel()
+
+	chunked := []string{"chunked"}
+
+	tests := []struct {
+		name     string
+		data     string
+		length   int64
+		encoding []string
+		end      int
+		complete bool
+	}{
+		{name: "no body", data: "GET / HTTP/1.1\r\n\r\nGET", end: 18, complete: true},
+		{name: "content length", data: "POST / HTTP/1.1\n\nabcdef", length: 3, end: 20, complete: true},
+		{name: "partial body", data: "POST / HTTP/1.1\n\nab", length: 3, end: 19},
+		{name: "partial head", data: "POST / HTTP/1.1\r\n", end: 17},
+		{name: "chunked", data: "POST / HTTP/1.1\n\n1\nA\n0\n\nX", encoding: chunked, end: 24, complete: true},
+		{name: "partial chunk", data: "POST / HTTP/1.1\n\n5\nA", encoding: chunked, end: 20},
+		{name: "invalid chunk", data: "POST / HTTP/1.1\n\nZ\nA", encoding: chunked, end: 20},
+		{name: "partial trailer", data: "POST / HTTP/1.1\n\n0\nX: 1\n", encoding: chunked, end: 24},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := &http.Request{ContentLength: tt.length, TransferEncoding: tt.encoding}
+			end, complete := requestEnd([]byte(tt.data), r)
+			if end != tt.end || complete != tt.complete {
+				t.Errorf("requestEnd() = %v, %v, want %v, %v", end, complete, tt.end, tt.complete)
+			}
+		})
+	}
+}
+
+func Test_wireRecorderRequest(t *testing.T) {
+	t.Parallel()
+
+	data := "GET / HTTP/1.1\r\nHost: x\r\nX-N: 1\r\n\r\nGET / HTTP/1.1\r\nHost: x\r\nX-N: 2\r\n\r\n"
+
+	rec := &wireRecorder{max: DefaultWireMax}
+	rec.write([]byte(data))
+
+	reader := bufio.NewReader(strings.NewReader(data))
+
+	for _, want := range []string{"X-N: 1", "X-N: 2"} {
+		r, err := http.ReadRequest(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := rec.request(r)
+		if end, _ := requestEnd(got, r); !strings.Contains(string(got[:end]), want) {
+			t.Errorf("request() = %q, want %s", got[:end], want)
+		}
+
+		// the previous header map must not be collected, its address could be reused by the next request
+		runtime.GC()
+	}
+}
@@ -0,0 +1,258 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare_test
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/szkiba/yare"
+)
+
+func wireServer(t *testing.T, opts ...yare.Option) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewUnstartedServer(yare.NewMapper().EchoHandlerWithOptions(opts...))
+	srv.Listener = yare.WireListener(srv.Listener, 0)
+	srv.Config.ConnContext = yare.WireContext
+	srv.Start()
+
+	return srv
+}
+
+func wireExchange(t *testing.T, srv *httptest.Server, data string, count int) []yare.Dict {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer conn.Close()
+
+	_, _ = conn.Write([]byte(data))
+
+	reader := bufio.NewReader(conn)
+	out := []yare.Dict{}
+
+	for i := 0; i < count; i++ {
+		resp, err := http.ReadResponse(reader, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		got, _ := yare.ParseJSON(body)
+		wire, _ := got["wire"].(map[string]interface{})
+		out = append(out, yare.Dict(wire))
+	}
+
+	return out
+}
+
+func TestWireDump(t *testing.T) {
+	t.Parallel()
+
+	srv := wireServer(t, yare.WithBody(true), yare.WithWire(yare.WireEscaped))
+	defer srv.Close()
+
+	chunked := "POST /a HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: chunked\r\nTrailer: X-Sum\r\n\r\n" +
+		"2;ext=1\r\nhi\r\n0\r\nX-Sum: 1\r\n\r\n"
+	plain := "GET /b HTTP/1.1\nHost:  x \n\n"
+
+	got := wireExchange(t, srv, chunked+plain, 2)
+
+	want := []yare.Dict{
+		{
+			"format": "escaped", "size": json.Number(strconv.Itoa(len(chunked))), "complete": true,
+			"data": "POST /a HTTP/1.1\\r\\n\nHost: x\\r\\n\nTransfer-Encoding: chunked\\r\\n\nTrailer: X-Sum\\r\\n\n\\r\\n\n" +
+				"2;ext=1\\r\\n\nhi\\r\\n\n0\\r\\n\nX-Sum: 1\\r\\n\n\\r\\n\n",
+		},
+		{
+			"format": "escaped", "size": json.Number(strconv.Itoa(len(plain))), "complete": true,
+			"data": "GET /b HTTP/1.1\\n\nHost:  x \\n\n\\n\n",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EchoHandler() wire = %v, want %v", got, want)
+	}
+}
+
+func TestWireDumpFormats(t *testing.T) {
+	t.Parallel()
+
+	request := "GET / HTTP/1.1\r\nHost: x\r\nAuthorization: secret\r\n\r\n"
+
+	r := yare.NewRedactor()
+	_ = r.AddKey(yare.RedactHeaders, "authorization")
+
+	query := yare.NewRedactor()
+	_ = query.AddKey(yare.RedactQuery, "token")
+
+	jsonpath := yare.NewRedactor()
+	_ = jsonpath.AddPath("$.body.password")
+
+	masked := yare.Dict{
+		"format": "raw", "size": json.Number(strconv.Itoa(len(request))), "complete": true,
+		"data": yare.DefaultRedactMask, "redacted": true,
+	}
+
+	tests := []struct {
+		name string
+		opts []yare.Option
+		want yare.Dict
+	}{
+		{
+			name: "raw",
+			opts: []yare.Option{yare.WithWire(yare.WireRaw)},
+			want: yare.Dict{"format": "raw", "size": json.Number(strconv.Itoa(len(request))), "complete": true, "data": request},
+		},
+		{
+			name: "hex",
+			opts: []yare.Option{yare.WithWire(yare.WireHex)},
+			want: yare.Dict{
+				"format": "hex", "size": json.Number(strconv.Itoa(len(request))), "complete": true, "data": hex.Dump([]byte(request)),
+			},
+		},
+		{
+			name: "redacted",
+			opts: []yare.Option{yare.WithWire(yare.WireRaw), yare.WithRedactor(r)},
+			want: masked,
+		},
+		{
+			name: "redacted query",
+			opts: []yare.Option{yare.WithWire(yare.WireRaw), yare.WithRedactor(query)},
+			want: masked,
+		},
+		{
+			name: "redacted path",
+			opts: []yare.Option{yare.WithWire(yare.WireRaw), yare.WithRedactor(jsonpath)},
+			want: masked,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := wireServer(t, tt.opts...)
+			defer srv.Close()
+
+			if got := wireExchange(t, srv, request, 1); !reflect.DeepEqual(got[0], tt.want) {
+				t.Errorf("EchoHandler() wire = %v, want %v", got[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestWireKeepAlive(t *testing.T) {
+	t.Parallel()
+
+	first := "GET /a HTTP/1.1\r\nHost: x\r\nX-Seq: 1\r\nContent-Length: 2\r\n\r\nhi"
+	second := "GET /a HTTP/1.1\r\nHost: x\r\nX-Seq: 2\r\n\r\n"
+
+	tests := []struct {
+		name      string
+		pipelined bool
+	}{
+		{name: "sequential"},
+		{name: "pipelined", pipelined: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			srv := wireServer(t, yare.WithBody(true), yare.WithRaw(true), yare.WithWire(yare.WireRaw))
+			defer srv.Close()
+
+			conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer conn.Close()
+
+			reader := bufio.NewReader(conn)
+			requests := []string{first, second}
+
+			if tt.pipelined {
+				_, _ = conn.Write([]byte(first + second))
+			}
+
+			for i, request := range requests {
+				if !tt.pipelined {
+					_, _ = conn.Write([]byte(request))
+				}
+
+				resp, err := http.ReadResponse(reader, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				body, _ := ioutil.ReadAll(resp.Body)
+				resp.Body.Close()
+
+				got, _ := yare.ParseJSON(body)
+				wire, _ := got["wire"].(map[string]interface{})
+
+				if wire["data"] != request {
+					t.Errorf("EchoHandler() request %d wire data = %q, want %q", i, wire["data"], request)
+				}
+
+				raw, _ := got["raw"].(map[string]interface{})
+				headers, _ := raw["headers"].([]interface{})
+				want := map[string]interface{}{"name": "X-Seq", "value": strconv.Itoa(i + 1)}
+
+				if len(headers) < 2 || !reflect.DeepEqual(headers[1], want) {
+					t.Errorf("EchoHandler() request %d raw headers = %v, want %v second", i, headers, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWithWireError(t *testing.T) {
+	t.Parallel()
+
+	if _, err := yare.MapRequestWithOptions(newRequest(par{}), yare.WithWire("dummy")); err == nil {
+		t.Error("MapRequestWithOptions() error is nil")
+	}
+
+	got, err := yare.MapRequestWithOptions(newRequest(par{}), yare.WithWire(yare.WireRaw))
+	if err != nil {
+		t.Errorf("MapRequestWithOptions() error = %v", err)
+	}
+
+	if _, ok := got["wire"]; ok {
+		t.Error("MapRequestWithOptions() wire section for not recorded request")
+	}
+}
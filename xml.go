@@ -0,0 +1,150 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	errXMLNoRoot        = errors.New("xml: missing root element")
+	errXMLMultipleRoots = errors.New("xml: multiple root elements")
+	errXMLUnbalanced    = errors.New("xml: unexpected end element")
+	errXMLUnexpectedEOF = errors.New("xml: unexpected EOF")
+)
+
+type xmlNode struct {
+	name string
+	dict Dict
+	text strings.Builder
+}
+
+// ParseXML is a ParserFunc for parsing XML to Dict.
+//
+// The root element is mapped to a single key Dict. Elements with only text content are mapped to string,
+// other elements to Dict, where attributes are prefixed with "@", text content stored as "#text"
+// and repeated child elements are collected into arrays. Namespace prefixes and declarations are preserved
+// as they appear in the document.
+//
+// Can use as RegisterContentType parser argument.
+func ParseXML(in []byte) (Dict, error) {
+	d := xml.NewDecoder(bytes.NewReader(in))
+	d.CharsetReader = charsetReader
+	stack := []*xmlNode{}
+
+	var root Dict
+
+	for {
+		tok, err := d.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, wrapError(err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if root != nil {
+				return nil, wrapError(fmt.Errorf("%w: %s", errXMLMultipleRoots, xmlName(t.Name)))
+			}
+
+			node := &xmlNode{name: xmlName(t.Name), dict: make(Dict)}
+			for _, attr := range t.Attr {
+				node.dict["@"+xmlName(attr.Name)] = attr.Value
+			}
+
+			stack = append(stack, node)
+		case xml.CharData:
+			if len(stack) != 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		case xml.EndElement:
+			if len(stack) == 0 || stack[len(stack)-1].name != xmlName(t.Name) {
+				return nil, wrapError(fmt.Errorf("%w: %s", errXMLUnbalanced, xmlName(t.Name)))
+			}
+
+			node := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			if len(stack) == 0 {
+				root = Dict{node.name: node.value()}
+			} else {
+				stack[len(stack)-1].add(node.name, node.value())
+			}
+		}
+	}
+
+	if len(stack) != 0 {
+		return nil, wrapError(errXMLUnexpectedEOF)
+	}
+
+	if root == nil {
+		return nil, wrapError(errXMLNoRoot)
+	}
+
+	return root, nil
+}
+
+func xmlName(name xml.Name) string {
+	if len(name.Space) == 0 {
+		return name.Local
+	}
+
+	return name.Space + ":" + name.Local
+}
+
+func (n *xmlNode) value() interface{} {
+	text := strings.TrimSpace(n.text.String())
+
+	if len(n.dict) == 0 {
+		return text
+	}
+
+	if len(text) != 0 {
+		n.dict["#text"] = text
+	}
+
+	return n.dict
+}
+
+func (n *xmlNode) add(name string, value interface{}) {
+	prev, ok := n.dict[name]
+	if !ok {
+		n.dict[name] = value
+
+		return
+	}
+
+	if arr, ok := prev.([]interface{}); ok {
+		n.dict[name] = append(arr, value)
+	} else {
+		n.dict[name] = []interface{}{prev, value}
+	}
+}
@@ -0,0 +1,147 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/szkiba/yare"
+)
+
+func TestParseXML(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		in string
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		want    yare.Dict
+		wantErr bool
+	}{
+		{
+			name: "text",
+			args: args{in: `<?xml version="1.0"?><foo>bar</foo>`},
+			want: yare.Dict{"foo": "bar"},
+		},
+		{
+			name: "empty element",
+			args: args{in: `<foo/>`},
+			want: yare.Dict{"foo": ""},
+		},
+		{
+			name: "attributes",
+			args: args{in: `<foo id="1" lang="en">bar</foo>`},
+			want: yare.Dict{"foo": yare.Dict{"@id": "1", "@lang": "en", "#text": "bar"}},
+		},
+		{
+			name: "repeated",
+			args: args{in: `<list><item>1</item><item>2</item><item id="3"/><other/></list>`},
+			want: yare.Dict{"list": yare.Dict{
+				"item":  []interface{}{"1", "2", yare.Dict{"@id": "3"}},
+				"other": "",
+			}},
+		},
+		{
+			name: "namespaces",
+			args: args{in: `
+				<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope" xmlns="urn:foo">
+					<soap:Body><Hello>World</Hello></soap:Body>
+				</soap:Envelope>`},
+			want: yare.Dict{"soap:Envelope": yare.Dict{
+				"@xmlns:soap": "http://www.w3.org/2003/05/soap-envelope",
+				"@xmlns":      "urn:foo",
+				"soap:Body":   yare.Dict{"Hello": "World"},
+			}},
+		},
+		{
+			name: "mixed",
+			args: args{in: `<p>Hello <b>World</b>!<!-- comment --></p>`},
+			want: yare.Dict{"p": yare.Dict{"b": "World", "#text": "Hello !"}},
+		},
+		{
+			name: "encoding",
+			args: args{in: "<?xml version=\"1.0\" encoding=\"ISO-8859-2\"?><foo>t\xfck\xf5r</foo>"},
+			want: yare.Dict{"foo": "tükőr"},
+		},
+		{
+			name: "encoding converted",
+			args: args{in: `<?xml version="1.0" encoding="ISO-8859-2"?><foo>tükőr</foo>`},
+			want: yare.Dict{"foo": "tükőr"},
+		},
+		{
+			name:    "unknown encoding",
+			args:    args{in: "<?xml version=\"1.0\" encoding=\"dummy\"?><foo>\xfc</foo>"},
+			wantErr: true,
+		},
+		{
+			name:    "unbalanced",
+			args:    args{in: `<foo></bar>`},
+			wantErr: true,
+		},
+		{
+			name:    "unterminated",
+			args:    args{in: `<foo><bar></bar>`},
+			wantErr: true,
+		},
+		{
+			name:    "multiple roots",
+			args:    args{in: `<foo/><bar/>`},
+			wantErr: true,
+		},
+		{
+			name:    "invalid",
+			args:    args{in: "not a xml"},
+			wantErr: true,
+		},
+		{
+			name:    "syntax",
+			args:    args{in: "<foo"},
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			args:    args{in: ""},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := yare.ParseXML([]byte(tt.args.in))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseXML() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseXML() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare
+
+import (
+	"errors"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+var errNotMapping = errors.New("top level value is not a mapping")
+
+// ParseYAML is a ParserFunc for parsing YAML document to Dict.
+//
+// Only the first document of a multi-document stream is parsed.
+// Can use as RegisterContentType parser argument.
+func ParseYAML(in []byte) (Dict, error) {
+	var v interface{}
+
+	if err := yaml.Unmarshal(in, &v); err != nil {
+		return nil, wrapError(err)
+	}
+
+	m, ok := normalizeYAML(v).(map[string]interface{})
+	if !ok {
+		return nil, wrapError(fmt.Errorf("yaml: %w", errNotMapping))
+	}
+
+	return Dict(m), nil
+}
+
+// normalizeYAML converts mappings with non-string keys to map[string]interface{} for JSON compatibility.
+func normalizeYAML(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = normalizeYAML(e)
+		}
+
+		return t
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(t))
+
+		for k, e := range t {
+			out[fmt.Sprint(k)] = normalizeYAML(e)
+		}
+
+		return out
+	case []interface{}:
+		for i, e := range t {
+			t[i] = normalizeYAML(e)
+		}
+
+		return t
+	default:
+		return v
+	}
+}
@@ -0,0 +1,92 @@
+// MIT License
+//
+// Copyright (c) 2021 Iván Szkiba
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package yare_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/szkiba/yare"
+)
+
+func TestParseYAML(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		in string
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		want    yare.Dict
+		wantErr bool
+	}{
+		{
+			name: "normal",
+			args: args{in: "foo: bar\ncount: 2\nlist:\n  - 1\n  - a\nnested:\n  1: one\n  true: yes\n"},
+			want: yare.Dict{
+				"foo":    "bar",
+				"count":  2,
+				"list":   []interface{}{1, "a"},
+				"nested": map[string]interface{}{"1": "one", "true": "yes"},
+			},
+		},
+		{
+			name: "multi document",
+			args: args{in: "foo: bar\n---\nbar: foo\n"},
+			want: yare.Dict{"foo": "bar"},
+		},
+		{
+			name:    "sequence",
+			args:    args{in: "- foo\n- bar\n"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid",
+			args:    args{in: "foo: [bar"},
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			args:    args{in: ""},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := yare.ParseYAML([]byte(tt.args.in))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseYAML() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseYAML() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}